@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/web3go/provider"
+	"github.com/DSiSc/web3go/rpc"
+	"github.com/DSiSc/web3go/web3"
+)
+
+const (
+	// gatewayTimeout bounds the requests of the web3 provider to gateways
+	// configured without apigateway.timeout.
+	gatewayTimeout = 30 * time.Second
+	// pingTimeout bounds the connectivity check before switching gateways.
+	pingTimeout = 5 * time.Second
+)
+
+// NewHTTPClient creates the http client talking to the api gateway, trusting
+// its CA bundle and presenting its client certificate if configured.
+func NewHTTPClient(gw *config.ApiGatewayConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: gw.TLS.InsecureSkipVerify}
+	if gw.TLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(gw.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA bundle %s", gw.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if gw.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(gw.TLS.CertFile, gw.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// SetAuthHeader adds the basic or bearer authentication of the gateway to h.
+func SetAuthHeader(h http.Header, gw *config.ApiGatewayConfig) {
+	switch {
+	case gw.Auth.Token != "":
+		h.Set("Authorization", "Bearer "+gw.Auth.Token)
+	case gw.Auth.Username != "":
+		req := http.Request{Header: h}
+		req.SetBasicAuth(gw.Auth.Username, gw.Auth.Password)
+	}
+}
+
+// gatewayProvider is a web3 provider posting to the full gateway URL with the
+// transport security and authentication of the gateway configuration. The
+// providers of web3go only know about host and port.
+type gatewayProvider struct {
+	gw     *config.ApiGatewayConfig
+	client *http.Client
+}
+
+// NewGatewayClient creates the http client of requests to the api gateway,
+// bounded by apigateway.timeout.
+func NewGatewayClient(gw *config.ApiGatewayConfig) (*http.Client, error) {
+	client, err := NewHTTPClient(gw)
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = gw.Timeout
+	if client.Timeout == 0 {
+		client.Timeout = gatewayTimeout
+	}
+	return client, nil
+}
+
+// NewProvider creates the web3 provider of the api gateway.
+func NewProvider(gw *config.ApiGatewayConfig) (provider.Provider, error) {
+	client, err := NewGatewayClient(gw)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayProvider{gw: gw, client: client}, nil
+}
+
+func (p *gatewayProvider) IsConnected() bool {
+	var result map[string]interface{}
+	return p.SendRequest(&result, rpc.GetDefaultMethod().NewRequest("net_listening")) == nil
+}
+
+func (p *gatewayProvider) SendRequest(v interface{}, op rpc.Request) error {
+	body, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	return post(p.client, p.gw, body, v)
+}
+
+func (p *gatewayProvider) GetRPCMethod() rpc.RPC {
+	return rpc.GetDefaultMethod()
+}
+
+// Ping checks that the api gateway answers JSON-RPC requests.
+func Ping(gw *config.ApiGatewayConfig) error {
+	client, err := NewHTTPClient(gw)
+	if err != nil {
+		return err
+	}
+	client.Timeout = pingTimeout
+
+	// an error response also proves a JSON-RPC endpoint
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"net_version","params":[]}`)
+	if err := post(client, gw, body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Result) == 0 && len(resp.Error) == 0 {
+		return errors.New("api gateway sent no JSON-RPC response")
+	}
+	return nil
+}
+
+// Error is a JSON-RPC error answered by the api gateway.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Call sends a JSON-RPC request with the given raw params to the gateway and
+// returns the raw result. Errors answered by the gateway are returned as *Error.
+func Call(client *http.Client, gw *config.ApiGatewayConfig, method string, params json.RawMessage) (json.RawMessage, error) {
+	if len(params) == 0 {
+		params = json.RawMessage("[]")
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}
+	if err := post(client, gw, body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// Modules returns the api modules the gateway advertises with rpc_modules,
+// keyed by namespace.
+func Modules(client *http.Client, gw *config.ApiGatewayConfig) (map[string]string, error) {
+	result, err := Call(client, gw, "rpc_modules", nil)
+	if err != nil {
+		return nil, err
+	}
+	var modules map[string]string
+	if err := json.Unmarshal(result, &modules); err != nil {
+		return nil, fmt.Errorf("invalid rpc_modules result: %v", err)
+	}
+	return modules, nil
+}
+
+// post sends a JSON-RPC request body to the gateway and decodes the response into v.
+func post(client *http.Client, gw *config.ApiGatewayConfig, body []byte, v interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, gw.Endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	SetAuthHeader(req.Header, gw)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("api gateway returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// NewWeb3 creates a web3 instance talking to the api gateway.
+func NewWeb3(gw *config.ApiGatewayConfig) (*web3.Web3, error) {
+	if gw == nil {
+		return nil, errors.New("api gateway not configured")
+	}
+	p, err := NewProvider(gw)
+	if err != nil {
+		return nil, err
+	}
+	return web3.NewWeb3(p), nil
+}
+
+// NewWeb3WithClient creates a web3 instance talking to the api gateway through
+// client, whose transport is expected to match the TLS settings of gw.
+func NewWeb3WithClient(gw *config.ApiGatewayConfig, client *http.Client) *web3.Web3 {
+	return web3.NewWeb3(&gatewayProvider{gw: gw, client: client})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAuthHeader(t *testing.T) {
+	assert := assert.New(t)
+
+	h := http.Header{}
+	SetAuthHeader(h, &config.ApiGatewayConfig{Auth: config.AuthConfig{Token: "secret"}})
+	assert.Equal("Bearer secret", h.Get("Authorization"))
+
+	h = http.Header{}
+	SetAuthHeader(h, &config.ApiGatewayConfig{Auth: config.AuthConfig{Username: "astraia", Password: "pw"}})
+	assert.Equal("Basic YXN0cmFpYTpwdw==", h.Get("Authorization"))
+
+	h = http.Header{}
+	SetAuthHeader(h, &config.ApiGatewayConfig{})
+	assert.Equal("", h.Get("Authorization"))
+}
+
+func TestNewHTTPClientCAFile(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	// the test server certificate is not trusted without the CA bundle
+	client, err := NewHTTPClient(&config.ApiGatewayConfig{})
+	assert.Nil(err)
+	_, err = client.Get(server.URL)
+	assert.NotNil(err)
+
+	dir, _ := ioutil.TempDir("", "gateway")
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	ioutil.WriteFile(caFile, ca, 0644)
+
+	client, err = NewHTTPClient(&config.ApiGatewayConfig{TLS: config.TLSConfig{CAFile: caFile}})
+	assert.Nil(err)
+	resp, err := client.Get(server.URL + "/rpc/v1")
+	assert.Nil(err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal("/rpc/v1", string(body))
+
+	client, err = NewHTTPClient(&config.ApiGatewayConfig{TLS: config.TLSConfig{InsecureSkipVerify: true}})
+	assert.Nil(err)
+	_, err = client.Get(server.URL)
+	assert.Nil(err)
+
+	_, err = NewHTTPClient(&config.ApiGatewayConfig{TLS: config.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}})
+	assert.NotNil(err)
+}
+
+func TestPing(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path != "/rpc":
+			w.Write([]byte("not json-rpc"))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"1"}`))
+		}
+	}))
+	defer server.Close()
+
+	gw := &config.ApiGatewayConfig{URL: server.URL + "/rpc", Auth: config.AuthConfig{Token: "secret"}}
+	assert.Nil(Ping(gw))
+
+	gw.Auth.Token = "wrong"
+	assert.EqualError(Ping(gw), "api gateway returned 401 Unauthorized")
+
+	gw.Auth.Token, gw.URL = "secret", server.URL+"/other"
+	assert.NotNil(Ping(gw))
+}
+
+func TestModules(t *testing.T) {
+	assert := assert.New(t)
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch method = req.Method; method {
+		case "rpc_modules":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"eth":"1.0","net":"1.0"}}`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	gw := &config.ApiGatewayConfig{URL: server.URL}
+	client, err := NewGatewayClient(gw)
+	assert.Nil(err)
+	assert.Equal(gatewayTimeout, client.Timeout)
+	modules, err := Modules(client, gw)
+	assert.Nil(err)
+	assert.Equal(map[string]string{"eth": "1.0", "net": "1.0"}, modules)
+
+	_, err = Call(client, gw, "eth_unknown", nil)
+	assert.Equal("eth_unknown", method)
+	assert.Equal(&Error{Code: -32601, Message: "method not found"}, err)
+}
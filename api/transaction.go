@@ -2,15 +2,12 @@ package api
 
 import (
 	"errors"
-	"flag"
 	"fmt"
 	"github.com/DSiSc/craft/types"
 	"github.com/DSiSc/astraia/config"
 	"github.com/DSiSc/wallet/common"
 	local "github.com/DSiSc/wallet/core/types"
 	web3cmn "github.com/DSiSc/web3go/common"
-	"github.com/DSiSc/web3go/provider"
-	"github.com/DSiSc/web3go/rpc"
 	"github.com/DSiSc/web3go/web3"
 	"strconv"
 )
@@ -31,18 +28,14 @@ func SendTransaction(tx *types.Transaction) (common.Hash, error) {
 		data = ""
 	}
 
-	configHostName := config.GetApiGatewayHostName()
-	hostname := flag.String("hostname", configHostName, "The ethereum client RPC host")
-	configPort := config.GetApiGatewayPort()
-	port := flag.String("port", configPort, "The ethereum client RPC port")
-	verbose := flag.Bool("verbose", true, "Print verbose messages")
-
-	if *verbose {
-		fmt.Printf("Connect to %s:%s\n", *hostname, *port)
+	conf, err := config.Get()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	web3, err := NewWeb3(&conf.ApiGateway)
+	if err != nil {
+		return common.Hash{}, err
 	}
-
-	provider := provider.NewHTTPProvider(*hostname+":"+*port, rpc.GetDefaultMethod())
-	web3 := web3.NewWeb3(provider)
 
 	req := &web3cmn.TransactionRequest{
 		From:     from,
@@ -59,18 +52,14 @@ func SendTransaction(tx *types.Transaction) (common.Hash, error) {
 
 func SendRawTransaction(tx *types.Transaction) (common.Hash, error) {
 
-	configHostName := config.GetApiGatewayHostName()
-	hostname := flag.String("hostname", configHostName, "The ethereum client RPC host")
-	configPort := config.GetApiGatewayPort()
-	port := flag.String("port", configPort, "The ethereum client RPC port")
-	verbose := flag.Bool("verbose", false, "Print verbose messages")
-
-	if *verbose {
-		fmt.Printf("Connect to %s:%s\n", *hostname, *port)
+	conf, err := config.Get()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	web3, err := NewWeb3(&conf.ApiGateway)
+	if err != nil {
+		return common.Hash{}, err
 	}
-
-	provider := provider.NewHTTPProvider(*hostname+":"+*port, rpc.GetDefaultMethod())
-	web3 := web3.NewWeb3(provider)
 
 	txBytes, _ := local.EncodeToRLP(tx)
 	hash, err := web3.Eth.SendRawTransaction(txBytes)
@@ -124,4 +113,29 @@ func GetBalance(web *web3.Web3, addr string, quantity string) (string, error){
 	}
 	result := fmt.Sprintf("0x%x", count.Uint64())
 	return result, err
-}
\ No newline at end of file
+}
+func GetGasPrice(web *web3.Web3) (string, error) {
+	if web == nil {
+		return "", errors.New("GetGasPrice has call error web is nil")
+	}
+
+	price, err := web.Eth.GasPrice()
+	if err != nil {
+		return "", err
+	}
+	result := fmt.Sprintf("0x%x", price)
+	return result, err
+}
+
+func EstimateGas(web *web3.Web3, req *web3cmn.TransactionRequest) (string, error) {
+	if web == nil {
+		return "", errors.New("EstimateGas has call error web is nil")
+	}
+
+	gas, err := web.Eth.EstimateGas(req)
+	if err != nil {
+		return "", err
+	}
+	result := fmt.Sprintf("0x%x", gas)
+	return result, err
+}
@@ -24,16 +24,21 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"net/url"
+	"net"
+	"net/http"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"sync/atomic"
 
-	"github.com/DSiSc/crypto-suite/rlp"
 	"github.com/DSiSc/astraia/api"
 	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/hdwallet"
+	"github.com/DSiSc/astraia/log"
+	"github.com/DSiSc/astraia/rules"
+	"github.com/DSiSc/astraia/secrets"
 	"github.com/DSiSc/p2p/common"
 	"github.com/DSiSc/web3go/web3"
-	"github.com/DSiSc/craft/log"
 
 	ctypes "github.com/DSiSc/craft/types"
 	"github.com/DSiSc/wallet/accounts/keystore"
@@ -110,9 +115,34 @@ type Client struct {
 
 	//use to manager keystore wallets
 	keystore *keystore.KeyStore
+	keydir   string
 
-	//use to call apigateway
-	web3 *web3.Web3
+	//use to derive keystore accounts from a mnemonic
+	hdwallet *hdwallet.Store
+
+	//use to sign transactions, the keystore unless personal.openWallet switched it
+	signer Signer
+	//signer waiting for a PIN or passphrase before it replaces signer
+	pendingSigner Signer
+
+	//use to approve signing requests, every request is approved if nil
+	rules *rules.Engine
+
+	//use to look up passwords not passed by the caller
+	passwords secrets.Provider
+
+	//network in use, nil if none
+	network *config.Network
+
+	//use to call apigateway, replaced by SetGateway
+	web3       *web3.Web3
+	gateway    config.ApiGatewayConfig
+	httpClient *http.Client // nil to derive it from gateway
+	gwClient   *http.Client // carries the requests forwarded to gateway
+	web3Mu     sync.RWMutex
+	offline    int32 // set while the gateway is unreachable, see CheckGateway
+
+	log *log.Logger
 
 	// for dispatch
 	close       chan struct{}
@@ -170,21 +200,30 @@ func Dial(rawurl string) (*Client, error) {
 	return DialContext(context.Background(), rawurl)
 }
 
+// DialConfig creates a new RPC client like Dial, whose local handlers use cfg
+// instead of the configuration loaded by config.Get.
+func DialConfig(rawurl string, cfg *config.Config) (*Client, error) {
+	return dialContext(context.Background(), rawurl, cfg)
+}
+
 // DialContext creates a new RPC client, just like Dial.
 //
 // The context is used to cancel or time out the initial connection establishment. It does
 // not affect subsequent interactions with the client.
 func DialContext(ctx context.Context, rawurl string) (*Client, error) {
-	u, err := url.Parse(rawurl)
+	cfg, err := config.Get()
 	if err != nil {
 		return nil, err
 	}
-	switch u.Scheme {
-	case "http", "https":
-		return DialHTTP(rawurl)
-	default:
-		return nil, fmt.Errorf("no known transport for URL scheme %q", u.Scheme)
+	return dialContext(ctx, rawurl, cfg)
+}
+
+func dialContext(ctx context.Context, rawurl string, cfg *config.Config) (*Client, error) {
+	opts, err := configOptions(cfg, nil)
+	if err != nil {
+		return nil, err
 	}
+	return DialWithOptions(ctx, rawurl, opts)
 }
 
 // Client retrieves the client from the context, if any. This can be used to perform
@@ -194,38 +233,35 @@ func ClientFromContext(ctx context.Context) (*Client, bool) {
 	return client, ok
 }
 
-func newClient(initctx context.Context, connect reconnectFunc) (*Client, error) {
+func newClient(initctx context.Context, opts *Options, connect reconnectFunc) (*Client, error) {
 	conn, err := connect(initctx)
 	if err != nil {
 		return nil, err
 	}
 	//c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
-	c := initClient(conn)
+	c, err := initClient(conn, opts)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
 	c.reconnectFunc = connect
 	return c, nil
 }
 
 //func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
-func initClient(conn ServerCodec) *Client {
+func initClient(conn ServerCodec, opts *Options) (*Client, error) {
 	_, isHTTP := conn.(*httpConn)
 
-	keyStoreDir :=  keystore.KeyStoreScheme
-	scryptN, scryptP, keydir, err := wutils.AccountConfig(keyStoreDir)
-	if err != nil {
-		fmt.Println("client init failed, err = ", err)
-	}
-
-	_keystore := keystore.NewKeyStore(keydir, scryptN, scryptP)
-
-	hostname := config.GetApiGatewayHostName()
-	port := config.GetApiGatewayPort()
-	web, _ := wutils.NewWeb3(hostname, port, false)
 	c := &Client{
 		//idgen:       idgen,
 		isHTTP:      isHTTP,
 		isLocal:     true,
-		keystore:    _keystore,
-		web3:        web,
+		log:         opts.Logger,
+		signer:      opts.Signer,
+		passwords:   opts.Passwords,
+		network:     opts.Network,
+		httpClient:  opts.HTTPClient,
+		gateway:     *opts.Gateway,
 		//services:    services,
 		writeConn:   conn,
 		close:       make(chan struct{}),
@@ -238,18 +274,39 @@ func initClient(conn ServerCodec) *Client {
 		reqSent:     make(chan error, 1),
 		reqTimeout:  make(chan *requestOp),
 	}
+	if c.log == nil {
+		c.log = log.Root()
+	}
+	// the provider keeps its own copy, c.gateway is replaced by SetGateway
+	gw := *opts.Gateway
+	web, gwClient, err := c.dialGateway(&gw)
+	if err != nil {
+		return nil, fmt.Errorf("api gateway: %v", err)
+	}
+	c.web3, c.gwClient = web, gwClient
+	if opts.KeystoreDir != "" {
+		scryptN, scryptP := opts.ScryptN, opts.ScryptP
+		if scryptN == 0 || scryptP == 0 {
+			scryptN, scryptP = keystore.StandardScryptN, keystore.StandardScryptP
+		}
+		c.UseKeystore(opts.KeystoreDir, scryptN, scryptP)
+	}
 	if !isHTTP {
 		//go c.dispatch(conn)
 	}
-	return c
+	return c, nil
 }
 
-func (c *Client) setWeb3(web *web3.Web3) error {
-	if web == nil {
-		return errors.New("set web3 can't be nil")
+// UseKeystore replaces the keystore the local handlers sign with by the one in
+// keydir, encrypting new keys with the given scrypt parameters. The mnemonic
+// wallet is kept next to the keystore directory.
+func (c *Client) UseKeystore(keydir string, scryptN, scryptP int) {
+	ks := keystore.NewKeyStore(keydir, scryptN, scryptP)
+	c.keystore, c.keydir = ks, keydir
+	c.hdwallet = hdwallet.NewStore(filepath.Dir(keydir), ks, scryptN, scryptP)
+	if _, ok := c.signer.(*keystoreSigner); ok || c.signer == nil {
+		c.signer = NewKeystoreSigner(ks)
 	}
-	c.web3 = web
-	return nil
 }
 
 // RegisterName creates a service for the given receiver type under the given name. When no
@@ -456,6 +513,14 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 	result := []string{}
 	json.Unmarshal(msg.Params, &result)
 	jsonReusult := []byte{'{', '"', 'p', 'e', 'r', 's', 'o', 'n', 'a', 'l', '"', ':', '"', '1', '.', '0', '"', '}'}
+	// respErr is set by handlers which report failures as JSON-RPC errors
+	// instead of result strings, so the console can react on them
+	var respErr *jsonError
+
+	if err := c.checkOnline(msg.Method); err != nil {
+		op.resp <- &jsonrpcMessage{Method: msg.Method, Params: msg.Params, Error: err}
+		return nil
+	}
 
 	switch msg.Method {
 	case "personal_newAccount":
@@ -467,8 +532,17 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		break
 	case "personal_unlockAccount":
 		addr := result[0]
-		password := result[1]
-		err := wutils.Unlock(c.keystore, addr, password)
+		var password string
+		if len(result) > 1 {
+			password = result[1]
+		}
+		password, err := c.password(addr, password)
+		if err == nil && c.keystore == nil {
+			err = errNoKeystore
+		}
+		if err == nil {
+			err = wutils.Unlock(c.keystore, addr, password)
+		}
 		if err != nil {
 			msg := fmt.Sprintf("unlockAccount failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -476,18 +550,140 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		break
 	case "personal_lockAccount":
 		addr := result[0]
-		err := wutils.Lock(c.keystore, addr)
+		err := errNoKeystore
+		if c.keystore != nil {
+			err = wutils.Lock(c.keystore, addr)
+		}
 		if err != nil {
 			msg := fmt.Sprintf("lockAccount failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
 		}
 		break
 
+	case "personal_newMnemonicWallet":
+		//params: password, mnemonic(optional), account count(optional)
+		var rawMsg []json.RawMessage
+		err := json.Unmarshal(msg.Params, &rawMsg)
+		if err == nil && len(rawMsg) == 0 {
+			err = errors.New("password not specified")
+		}
+		if err != nil {
+			msg := fmt.Sprintf("personal_newMnemonicWallet failed, err = %v", err)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+
+		var password, mnemonic string
+		var count int
+		err = json.Unmarshal(rawMsg[0], &password)
+		if err == nil && len(rawMsg) > 1 {
+			err = json.Unmarshal(rawMsg[1], &mnemonic)
+		}
+		if err == nil && len(rawMsg) > 2 {
+			err = json.Unmarshal(rawMsg[2], &count)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("personal_newMnemonicWallet failed, err = %v", err)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+		if count <= 0 {
+			count = 1
+		}
+
+		generated := mnemonic == ""
+		if generated {
+			mnemonic, err = hdwallet.NewMnemonic(hdwallet.DefaultEntropyBits)
+			if err != nil {
+				msg := fmt.Sprintf("personal_newMnemonicWallet failed, err = %v", err)
+				jsonReusult, _ = json.Marshal(msg)
+				break
+			}
+		}
+		if c.hdwallet == nil {
+			msg := fmt.Sprintf("personal_newMnemonicWallet failed, err = %v", errNoKeystore)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+		wallet, err := c.hdwallet.Create(mnemonic, password, "", count)
+		if err != nil {
+			msg := fmt.Sprintf("personal_newMnemonicWallet failed, err = %v", err)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+
+		// only echo the mnemonic back if it was generated here, so the user can
+		// write it down
+		reply := map[string]interface{}{
+			"path":     wallet.BasePath,
+			"accounts": wallet.Accounts,
+		}
+		if generated {
+			reply["mnemonic"] = mnemonic
+		}
+		jsonReusult, _ = json.Marshal(reply)
+		break
+
+	case "personal_deriveAccount":
+		//params: account index, password
+		var rawMsg []json.RawMessage
+		err := json.Unmarshal(msg.Params, &rawMsg)
+		if err == nil && len(rawMsg) < 2 {
+			err = errors.New("account index and password not specified")
+		}
+		if err != nil {
+			msg := fmt.Sprintf("personal_deriveAccount failed, err = %v", err)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+
+		var index uint32
+		var password string
+		err = json.Unmarshal(rawMsg[0], &index)
+		if err == nil {
+			err = json.Unmarshal(rawMsg[1], &password)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("personal_deriveAccount failed, err = %v", err)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+
+		if c.hdwallet == nil {
+			msg := fmt.Sprintf("personal_deriveAccount failed, err = %v", errNoKeystore)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+		account, err := c.hdwallet.Derive(password, index)
+		if err != nil {
+			msg := fmt.Sprintf("personal_deriveAccount failed, err = %v", err)
+			jsonReusult, _ = json.Marshal(msg)
+			break
+		}
+		jsonReusult, _ = json.Marshal(account.Address)
+		break
+
+	case "personal_openWallet":
+		//params: wallet url, passphrase
+		var url, passphrase string
+		if len(result) > 0 {
+			url = result[0]
+		}
+		if len(result) > 1 {
+			passphrase = result[1]
+		}
+		if err := c.openWallet(url, passphrase); err != nil {
+			respErr = &jsonError{Code: openWalletErrorCode(err), Message: err.Error()}
+			break
+		}
+		jsonReusult, _ = json.Marshal(c.signer.URL())
+		break
+
 	case "eth_getBalance":
 		addr := result[0]
 		quantity := result[1]
 
-		count, err := api.GetBalance(c.web3, addr, quantity)
+		count, err := api.GetBalance(c.getWeb3(), addr, quantity)
 		if err != nil {
 			msg := fmt.Sprintf("eth_getBalance failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -501,7 +697,7 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		addr := result[0]
 		quantity := result[1]
 
-		count, err := api.GetTransactionCount(c.web3, addr, quantity)
+		count, err := api.GetTransactionCount(c.getWeb3(), addr, quantity)
 		if err != nil {
 			msg := fmt.Sprintf("eth_getTransactionCount failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -549,7 +745,7 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		break
 
 	case "eth_sendRawTransaction":
-		hash, err := wutils.SendRawTransactionWeb3(c.web3, result[0])
+		hash, err := wutils.SendRawTransactionWeb3(c.getWeb3(), result[0])
 		if err != nil {
 			msg := fmt.Sprintf("sendRawTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -560,7 +756,7 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 
 		break
 	case "eth_getTransactionByHash":
-		tx, err := api.GetTransactionByHash(c.web3, result[0])
+		tx, err := api.GetTransactionByHash(c.getWeb3(), result[0])
 		if err != nil {
 			msg := fmt.Sprintf("eth_getTransactionByHash failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -572,16 +768,68 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 	case "eth_newWeb3":
 		hostname := result[0]
 		port := result[1]
-		web, err := wutils.NewWeb3(hostname, port, false)
-		if err != nil {
+		gw := c.Gateway()
+		gw.URL = "http://" + net.JoinHostPort(hostname, port)
+		if _, err := c.SetGateway(&gw); err != nil {
 			msg := fmt.Sprintf("eth_newWeb3 failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
 			break
 		}
-		c.setWeb3(web)
 		jsonReusult, _ = json.Marshal("new dial http:// " + hostname + ":" + port)
 		break
 
+	case "admin_setGateway", "lightclient_setGateway":
+		gw := c.Gateway()
+		gw.URL = result[0]
+		previous, err := c.SetGateway(&gw)
+		if err != nil {
+			respErr = &jsonError{Code: defaultErrorCode, Message: err.Error()}
+			break
+		}
+		jsonReusult, _ = json.Marshal(map[string]string{"previous": previous, "current": gw.Endpoint()})
+		break
+
+	case "admin_gateway":
+		gw := c.Gateway()
+		jsonReusult, _ = json.Marshal(gw.Endpoint())
+		break
+
+	case "lightclient_gateway":
+		jsonReusult, _ = json.Marshal(c.gatewayInfo())
+		break
+
+	case "lightclient_config":
+		jsonReusult, _ = json.Marshal(c.configInfo())
+		break
+
+	case "crosschain_status":
+		if len(result) == 0 {
+			respErr = &jsonError{Code: defaultErrorCode, Message: "transaction hash not specified"}
+			break
+		}
+		status, err := c.crossChainStatus(result[0])
+		if err != nil {
+			respErr = &jsonError{Code: defaultErrorCode, Message: err.Error()}
+			break
+		}
+		jsonReusult, _ = json.Marshal(status)
+		break
+
+	case "debug_verbosity":
+		level, err := parseVerbosity(msg.Params)
+		if err != nil {
+			respErr = &jsonError{Code: defaultErrorCode, Message: err.Error()}
+			break
+		}
+		previous := c.log.Level()
+		c.log.SetLevel(level)
+		jsonReusult, _ = json.Marshal(map[string]string{"previous": previous.String(), "current": level.String()})
+		break
+
+	case "rpc_modules":
+		jsonReusult, _ = json.Marshal(c.modules())
+		break
+
 	case "personal_signCrossTransaction":
 		var rawMsg []json.RawMessage
 		err := json.Unmarshal(msg.Params, &rawMsg)
@@ -617,8 +865,11 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 			break
 		}
 
+		// the password is optional, see the passwords section of the config file
 		var password string
-		err = json.Unmarshal(rawMsg[3], &password)
+		if len(rawMsg) > 3 {
+			err = json.Unmarshal(rawMsg[3], &password)
+		}
 		if err != nil {
 			msg := fmt.Sprintf("personal_signCrossTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -635,16 +886,19 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 
 		// inject payload(tx's byte code)
 		subTx := GetCrossSubTx(transaction, toAddr)
-		signed, err := wutils.SignTxByPassWord(&subTx, password)
+		err = c.approveTx(msg.Method, &subTx, &rules.CrossChain{Target: toAddr, ChainFlag: chainFlag, Inner: true})
 		if err != nil {
-			msg := fmt.Sprintf("personal_signCrossTransaction failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signCrossTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
 			break
 		}
-		data, err := rlp.EncodeToBytes(signed)
+		// the inner transaction runs on the target chain, whose chain ID is
+		// unknown here, so it is signed without replay protection
+		data, err := c.signTxFor(&subTx, password, 0)
 		if err != nil {
-			msg := fmt.Sprintf("personal_signCrossTransaction rlp encode failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signCrossTransaction failed, tx = %s, err = %v", result, err)
 			jsonReusult, _ = json.Marshal(msg)
+			break
 		}
 
 		//construct a input with some contract call args
@@ -663,16 +917,17 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		inputBytes := web3cmn.HexToBytes(inputStr)
 		transaction.Data.Payload = inputBytes
 
-		signed, err = wutils.SignTxByPassWord(&transaction, password)
+		err = c.approveTx(msg.Method, &transaction, &rules.CrossChain{Target: toAddr, ChainFlag: chainFlag})
 		if err != nil {
-			msg := fmt.Sprintf("personal_signCrossTransaction failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signCrossTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
 			break
 		}
-		data, err = rlp.EncodeToBytes(signed)
+		data, err = c.signTx(&transaction, password)
 		if err != nil {
-			msg := fmt.Sprintf("personal_signCrossTransaction rlp encode failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signCrossTransaction failed, tx = %s, err = %v", result, err)
 			jsonReusult, _ = json.Marshal(msg)
+			break
 		}
 
 		jsonReusult, _ = json.Marshal(wcommon.ToHex(data))
@@ -714,8 +969,11 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 			break
 		}
 
+		// the password is optional, see the passwords section of the config file
 		var password string
-		err = json.Unmarshal(rawMsg[3], &password)
+		if len(rawMsg) > 3 {
+			err = json.Unmarshal(rawMsg[3], &password)
+		}
 		if err != nil {
 			msg := fmt.Sprintf("personal_signCrossQueryTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -743,19 +1001,20 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		funcFilter := "0x15508866"
 		inputStr := funcFilter + input[2:]
 		inputBytes := web3cmn.HexToBytes(inputStr)
-		fmt.Println("<------->", string(inputStr))
+		c.log.Debug("Cross-chain query payload", "input", inputStr)
 		transaction.Data.Payload = inputBytes
 
-		signed, err := wutils.SignTxByPassWord(&transaction, password)
+		err = c.approveTx(msg.Method, &transaction, &rules.CrossChain{Target: fromAddr, ChainFlag: chainFlag})
 		if err != nil {
-			msg := fmt.Sprintf("personal_signCrossQueryTransaction failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signCrossQueryTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
 			break
 		}
-		data, err := rlp.EncodeToBytes(signed)
+		data, err := c.signTx(&transaction, password)
 		if err != nil {
-			msg := fmt.Sprintf("personal_signCrossQueryTransaction rlp encode failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signCrossQueryTransaction failed, tx = %s, err = %v", result, err)
 			jsonReusult, _ = json.Marshal(msg)
+			break
 		}
 
 		jsonReusult, _ = json.Marshal(wcommon.ToHex(data))
@@ -780,8 +1039,11 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 			break
 		}
 
+		// the password is optional, see the passwords section of the config file
 		var password string
-		err = json.Unmarshal(rawMsg[1], &password)
+		if len(rawMsg) > 1 {
+			err = json.Unmarshal(rawMsg[1], &password)
+		}
 		if err != nil {
 			msg := fmt.Sprintf("personal_signTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
@@ -795,17 +1057,18 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 			jsonReusult, _ = json.Marshal(msg)
 			break
 		}
-
-		signed, err := wutils.SignTxByPassWord(&transaction, password)
+		err = c.approveTx(msg.Method, &transaction, nil)
 		if err != nil {
-			msg := fmt.Sprintf("personal_signTransaction failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signTransaction failed, err = %v", err)
 			jsonReusult, _ = json.Marshal(msg)
 			break
 		}
-		data, err := rlp.EncodeToBytes(signed)
+
+		data, err := c.signTx(&transaction, password)
 		if err != nil {
-			msg := fmt.Sprintf("personal_signTransaction rlp encode failed, tx = %s, err = %v", result, err)
+			msg := fmt.Sprintf("personal_signTransaction failed, tx = %s, err = %v", result, err)
 			jsonReusult, _ = json.Marshal(msg)
+			break
 		}
 
 		jsonReusult, _ = json.Marshal(wcommon.ToHex(data))
@@ -813,13 +1076,31 @@ func (c *Client) sendLocal(ctx context.Context, op *requestOp, msg *jsonrpcMessa
 		break
 
 	default:
-		//jsonReusult, _ = json.Marshal("This function is not currently implemented")
+		// methods of the local namespaces without handler never leave the
+		// process, the others, admin and debug ones included, are served by
+		// the gateway
+		if !needsGateway(msg.Method) {
+			notFound := &methodNotFoundError{msg.Method}
+			respErr = &jsonError{Code: notFound.ErrorCode(), Message: notFound.Error()}
+			break
+		}
+		gw, client := c.gatewayConn()
+		res, err := api.Call(client, &gw, msg.Method, msg.Params)
+		if err != nil {
+			respErr = &jsonError{Code: defaultErrorCode, Message: err.Error()}
+			if gwErr, ok := err.(*api.Error); ok {
+				respErr.Code = gwErr.Code
+			}
+			break
+		}
+		jsonReusult = res
 	}
 
 	respmsg := jsonrpcMessage{
 		Method:msg.Method,
 		Params:msg.Params,
 		Result:jsonReusult,
+		Error:respErr,
 	}
 
 	op.resp <- &respmsg
@@ -853,7 +1134,7 @@ func (c *Client) reconnect(ctx context.Context) error {
 	}
 	newconn, err := c.reconnectFunc(ctx)
 	if err != nil {
-		log.Error("RPC client reconnect failed" + "err" + err.Error())
+		c.log.Error("RPC client reconnect failed", "err", err)
 		return err
 	}
 	select {
@@ -0,0 +1,19 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/DSiSc/astraia/log"
+)
+
+// parseVerbosity parses the parameters of debug_verbosity, a level number or
+// name such as 4 or "debug".
+func parseVerbosity(params json.RawMessage) (log.Level, error) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+		return 0, errors.New("expected a single verbosity argument")
+	}
+	return log.ParseLevel(strings.Trim(string(args[0]), `"`))
+}
@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DSiSc/astraia/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVerbosity(t *testing.T) {
+	assert := assert.New(t)
+	level, err := parseVerbosity(json.RawMessage(`[4]`))
+	assert.Nil(err)
+	assert.Equal(log.LvlDebug, level)
+
+	level, err = parseVerbosity(json.RawMessage(`["warn"]`))
+	assert.Nil(err)
+	assert.Equal(log.LvlWarn, level)
+
+	_, err = parseVerbosity(json.RawMessage(`[9]`))
+	assert.NotNil(err)
+	_, err = parseVerbosity(json.RawMessage(`[]`))
+	assert.NotNil(err)
+}
@@ -0,0 +1,226 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DSiSc/astraia/api"
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/web3go/web3"
+)
+
+// localModules are the namespaces served by the local handlers.
+var localModules = map[string]string{
+	"admin":       "1.0",
+	"crosschain":  "1.0",
+	"debug":       "1.0",
+	"lightclient": "1.0",
+	"personal":    "1.0",
+}
+
+// LocalModules returns the namespaces served by the local handlers, which are
+// available whether or not the api gateway is reachable.
+func (c *Client) LocalModules() map[string]string {
+	modules := make(map[string]string, len(localModules))
+	for name, version := range localModules {
+		modules[name] = version
+	}
+	return modules
+}
+
+// modules merges the local namespaces with the modules the api gateway
+// advertises, the local handlers taking precedence. Only the local namespaces
+// are returned while offline or if the gateway does not answer rpc_modules.
+func (c *Client) modules() map[string]string {
+	modules := c.LocalModules()
+	if !c.Online() {
+		return modules
+	}
+	gw, client := c.gatewayConn()
+	remote, err := api.Modules(client, &gw)
+	if err != nil {
+		c.log.Debug("Api gateway modules unavailable", "gateway", gw.Endpoint(), "err", err)
+		return modules
+	}
+	for name, version := range remote {
+		if _, ok := modules[name]; !ok {
+			modules[name] = version
+		}
+	}
+	return modules
+}
+
+// gatewayMethods are local handlers which talk to the api gateway.
+var gatewayMethods = map[string]bool{
+	"crosschain_status": true,
+}
+
+// offlineMethods are served without the api gateway although their namespace
+// is not a local one.
+var offlineMethods = map[string]bool{
+	"eth_newWeb3": true, // checks the new gateway itself
+	"rpc_modules": true,
+}
+
+// sharedModules are local namespaces which nodes serve as well. Only their
+// methods in localMethods are answered locally, the others are forwarded.
+var sharedModules = map[string]bool{
+	"admin": true,
+	"debug": true,
+}
+
+// localMethods are the methods of the shared namespaces with a local handler.
+var localMethods = map[string]bool{
+	"admin_gateway":    true,
+	"admin_setGateway": true,
+	"debug_verbosity":  true,
+}
+
+// needsGateway reports whether the handler of method talks to the api gateway.
+func needsGateway(method string) bool {
+	if gatewayMethods[method] {
+		return true
+	}
+	if offlineMethods[method] || localMethods[method] {
+		return false
+	}
+	namespace := method
+	if i := strings.Index(method, "_"); i >= 0 {
+		namespace = method[:i]
+	}
+	_, local := localModules[namespace]
+	return !local || sharedModules[namespace]
+}
+
+// Gateway returns the configuration of the api gateway the local handlers talk to.
+func (c *Client) Gateway() config.ApiGatewayConfig {
+	c.web3Mu.RLock()
+	defer c.web3Mu.RUnlock()
+	return c.gateway
+}
+
+// getWeb3 returns the web3 instance of the current api gateway.
+func (c *Client) getWeb3() *web3.Web3 {
+	c.web3Mu.RLock()
+	defer c.web3Mu.RUnlock()
+	return c.web3
+}
+
+// gatewayConn returns the current api gateway and the http client of the
+// requests forwarded to it.
+func (c *Client) gatewayConn() (config.ApiGatewayConfig, *http.Client) {
+	c.web3Mu.RLock()
+	defer c.web3Mu.RUnlock()
+	return c.gateway, c.gwClient
+}
+
+// dialGateway creates the web3 instance and the http client talking to gw,
+// sharing the http client of the options if one was given.
+func (c *Client) dialGateway(gw *config.ApiGatewayConfig) (*web3.Web3, *http.Client, error) {
+	client := c.httpClient
+	if client == nil {
+		var err error
+		if client, err = api.NewGatewayClient(gw); err != nil {
+			return nil, nil, err
+		}
+	}
+	return api.NewWeb3WithClient(gw, client), client, nil
+}
+
+// SetGateway checks that the api gateway gw answers and makes it the one the
+// local handlers talk to, returning the endpoint of the previous gateway. The
+// current gateway is kept if gw is invalid or unreachable.
+func (c *Client) SetGateway(gw *config.ApiGatewayConfig) (string, error) {
+	if err := gw.Validate(); err != nil {
+		return "", err
+	}
+	if err := api.Ping(gw); err != nil {
+		return "", fmt.Errorf("gateway %s unreachable: %v", gw.Endpoint(), err)
+	}
+	// the provider keeps its own copy of the gateway
+	current := *gw
+	web, client, err := c.dialGateway(&current)
+	if err != nil {
+		return "", err
+	}
+
+	c.web3Mu.Lock()
+	defer c.web3Mu.Unlock()
+	previous := c.gateway.Endpoint()
+	c.web3, c.gwClient, c.gateway = web, client, current
+	c.setOnline(true) // the new gateway just answered
+	return previous, nil
+}
+
+// Online reports whether the api gateway answered the last check. A client is
+// online until CheckGateway finds the gateway unreachable.
+func (c *Client) Online() bool {
+	return atomic.LoadInt32(&c.offline) == 0
+}
+
+// setOnline records the state of the gateway, reporting whether it changed.
+func (c *Client) setOnline(online bool) bool {
+	var offline int32
+	if !online {
+		offline = 1
+	}
+	return atomic.SwapInt32(&c.offline, offline) != offline
+}
+
+// CheckGateway pings the api gateway and records whether it is reachable.
+// While it is not, the client is offline: the handlers which need the gateway
+// fail right away while the keystore and signing handlers keep working.
+func (c *Client) CheckGateway() error {
+	gw := c.Gateway()
+	err := api.Ping(&gw)
+	if c.setOnline(err == nil) {
+		if err == nil {
+			c.log.Info("Api gateway reachable, leaving offline mode", "gateway", gw.Endpoint())
+		} else {
+			c.log.Warn("Api gateway unreachable, entering offline mode", "gateway", gw.Endpoint(), "err", err)
+		}
+	}
+	return err
+}
+
+// WatchGateway checks the api gateway every interval until the returned
+// function is called. notify, if not nil, is called with the new state each
+// time the client goes offline or comes back online.
+func (c *Client) WatchGateway(interval time.Duration, notify func(online bool)) (stop func()) {
+	quit := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				before := c.Online()
+				c.CheckGateway()
+				if online := c.Online(); online != before && notify != nil {
+					notify(online)
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+// checkOnline returns the error of methods which need the api gateway while
+// the client is offline.
+func (c *Client) checkOnline(method string) *jsonError {
+	if c.Online() || !needsGateway(method) {
+		return nil
+	}
+	gw := c.Gateway()
+	return &jsonError{
+		Code:    defaultErrorCode,
+		Message: fmt.Sprintf("%s unavailable offline: api gateway %s is unreachable", method, gw.Endpoint()),
+	}
+}
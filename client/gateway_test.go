@@ -0,0 +1,174 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGateway(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"1"}`))
+	}))
+	defer server.Close()
+
+	c := &Client{gateway: config.Default().ApiGateway}
+	gw := c.Gateway()
+	gw.URL = server.URL + "/rpc"
+	previous, err := c.SetGateway(&gw)
+	assert.Nil(err)
+	assert.Equal("http://127.0.0.1:47768", previous)
+	assert.Equal(server.URL+"/rpc", c.Gateway().Endpoint())
+	assert.NotNil(c.getWeb3())
+
+	// invalid and unreachable gateways keep the current one
+	gw.URL = "ftp://gateway.example.com"
+	_, err = c.SetGateway(&gw)
+	assert.NotNil(err)
+	server.Close()
+	gw.URL = server.URL
+	_, err = c.SetGateway(&gw)
+	assert.NotNil(err)
+	assert.Equal(server.URL+"/rpc", c.Gateway().Endpoint())
+}
+
+func TestOfflineMode(t *testing.T) {
+	assert := assert.New(t)
+	var down int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"1"}`))
+	}))
+	defer server.Close()
+
+	gw := config.Default().ApiGateway
+	gw.URL = server.URL
+	c, err := DialWithOptions(context.Background(), gw.URL, Options{
+		Gateway: &gw,
+		Logger:  log.New(ioutil.Discard, log.LvlInfo, log.FormatText),
+	})
+	assert.Nil(err)
+	assert.True(c.Online())
+	assert.Nil(c.CheckGateway())
+
+	atomic.StoreInt32(&down, 1)
+	assert.NotNil(c.CheckGateway())
+	assert.False(c.Online())
+
+	// handlers which need the gateway fail, local ones keep working
+	var balance string
+	err = c.Call(&balance, "eth_getBalance", "0x0000000000000000000000000000000000000000", "latest")
+	assert.EqualError(err, "eth_getBalance unavailable offline: api gateway "+server.URL+" is unreachable")
+	var endpoint string
+	assert.Nil(c.Call(&endpoint, "admin_gateway"))
+	assert.Equal(server.URL, endpoint)
+
+	changes := make(chan bool, 1)
+	stop := c.WatchGateway(10*time.Millisecond, func(online bool) { changes <- online })
+	defer stop()
+	atomic.StoreInt32(&down, 0)
+	select {
+	case online := <-changes:
+		assert.True(online)
+	case <-time.After(5 * time.Second):
+		t.Fatal("gateway not rechecked")
+	}
+	assert.True(c.Online())
+}
+
+func TestNeedsGateway(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(needsGateway("eth_getBalance"))
+	assert.True(needsGateway("net_version"))
+	assert.False(needsGateway("eth_newWeb3"))
+	assert.False(needsGateway("rpc_modules"))
+	assert.False(needsGateway("personal_signTransaction"))
+	assert.False(needsGateway("admin_gateway"))
+	assert.False(needsGateway("debug_verbosity"))
+	assert.True(needsGateway("admin_peers"))
+	assert.True(needsGateway("debug_traceTransaction"))
+	assert.False(needsGateway("lightclient_config"))
+	assert.True(needsGateway("crosschain_status"))
+}
+
+func TestGatewayModules(t *testing.T) {
+	assert := assert.New(t)
+	var forwarded []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		forwarded = append(forwarded, req.Method)
+		switch req.Method {
+		case "rpc_modules":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"eth":"1.0","net":"1.0","personal":"2.0"}}`))
+		case "net_version":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"7"}`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	gw := config.Default().ApiGateway
+	gw.URL = server.URL
+	c, err := DialWithOptions(context.Background(), gw.URL, Options{
+		Gateway: &gw,
+		Logger:  log.New(ioutil.Discard, log.LvlInfo, log.FormatText),
+	})
+	assert.Nil(err)
+
+	// the local handlers take precedence over the gateway
+	modules, err := c.SupportedModules()
+	assert.Nil(err)
+	assert.Equal("1.0", modules["personal"])
+	assert.Equal("1.0", modules["eth"])
+	assert.Equal("1.0", modules["net"])
+	assert.Equal(len(localModules)+2, len(modules))
+
+	// methods without local handler are forwarded
+	var version string
+	assert.Nil(c.Call(&version, "net_version"))
+	assert.Equal("7", version)
+	err = c.Call(&version, "net_peerCount")
+	assert.EqualError(err, "method not found")
+	assert.Equal(-32601, err.(Error).ErrorCode())
+
+	// unknown methods of the local namespaces are not forwarded
+	err = c.Call(&version, "personal_exportKey")
+	assert.EqualError(err, "the method personal_exportKey does not exist/is not available")
+	assert.Equal(-32601, err.(Error).ErrorCode())
+	assert.NotContains(forwarded, "personal_exportKey")
+
+	// the admin and debug methods of the node are, only the local ones are
+	// answered by the console
+	for _, method := range []string{"admin_peers", "debug_dumpBlock"} {
+		err = c.Call(&version, method)
+		assert.EqualError(err, "method not found")
+		assert.Contains(forwarded, method)
+	}
+	var endpoint string
+	assert.Nil(c.Call(&endpoint, "admin_gateway"))
+	assert.NotContains(forwarded, "admin_gateway")
+
+	// offline only the local namespaces are advertised
+	server.Close()
+	c.CheckGateway()
+	modules, err = c.SupportedModules()
+	assert.Nil(err)
+	assert.Equal(c.LocalModules(), modules)
+}
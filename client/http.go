@@ -31,7 +31,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/DSiSc/craft/log"
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
 	"github.com/rs/cors"
 )
 
@@ -107,17 +108,15 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
 func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
-	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	cfg, err := config.Get()
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("Accept", contentType)
-
-	initctx := context.Background()
-	return newClient(initctx, func(context.Context) (ServerCodec, error) {
-		return &httpConn{client: client, req: req, closed: make(chan interface{})}, nil
-	})
+	opts, err := configOptions(cfg, client)
+	if err != nil {
+		return nil, err
+	}
+	return DialWithOptions(context.Background(), endpoint, opts)
 }
 
 // DialHTTP creates a new RPC client that connects to an RPC server over HTTP.
@@ -215,15 +214,15 @@ func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv ht
 	// Make sure timeout values are meaningful
 	if timeouts.ReadTimeout < time.Second {
 
-		log.Warn("Sanitizing invalid HTTP read timeout %s %d, %s %d", "provided", timeouts.ReadTimeout, "updated", DefaultHTTPTimeouts.ReadTimeout)
+		log.Warn("Sanitizing invalid HTTP read timeout", "provided", timeouts.ReadTimeout, "updated", DefaultHTTPTimeouts.ReadTimeout)
 		timeouts.ReadTimeout = DefaultHTTPTimeouts.ReadTimeout
 	}
 	if timeouts.WriteTimeout < time.Second {
-		log.Warn("Sanitizing invalid HTTP write timeout %s %d, %s %d", "provided", timeouts.WriteTimeout, "updated", DefaultHTTPTimeouts.WriteTimeout)
+		log.Warn("Sanitizing invalid HTTP write timeout", "provided", timeouts.WriteTimeout, "updated", DefaultHTTPTimeouts.WriteTimeout)
 		timeouts.WriteTimeout = DefaultHTTPTimeouts.WriteTimeout
 	}
 	if timeouts.IdleTimeout < time.Second {
-		log.Warn("Sanitizing invalid HTTP idle timeout %s %d, %s %d", "provided", timeouts.IdleTimeout, "updated", DefaultHTTPTimeouts.IdleTimeout)
+		log.Warn("Sanitizing invalid HTTP idle timeout", "provided", timeouts.IdleTimeout, "updated", DefaultHTTPTimeouts.IdleTimeout)
 		timeouts.IdleTimeout = DefaultHTTPTimeouts.IdleTimeout
 	}
 	// Bundle and start the HTTP server
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/DSiSc/astraia/api"
+)
+
+// gatewayResult is the result of lightclient_gateway.
+type gatewayResult struct {
+	Endpoint string `json:"endpoint"`
+	Online   bool   `json:"online"`
+	Network  string `json:"network,omitempty"`
+	ChainID  uint64 `json:"chainId,omitempty"`
+	Explorer string `json:"explorer,omitempty"`
+}
+
+// configResult is the result of lightclient_config. It leaves out credentials.
+type configResult struct {
+	Gateway  string `json:"gateway"`
+	Timeout  string `json:"timeout"`
+	Auth     string `json:"auth"` // none, basic or token
+	Network  string `json:"network,omitempty"`
+	Keystore string `json:"keystore,omitempty"`
+	Signer   string `json:"signer,omitempty"`
+	Rules    bool   `json:"rules"`
+}
+
+// crossChainResult is the result of crosschain_status.
+type crossChainResult struct {
+	Hash        string `json:"hash"`
+	Status      string `json:"status"` // pending, success or failed
+	BlockNumber string `json:"blockNumber,omitempty"`
+}
+
+func (c *Client) gatewayInfo() *gatewayResult {
+	gw := c.Gateway()
+	info := &gatewayResult{Endpoint: gw.Endpoint(), Online: c.Online()}
+	if c.network != nil {
+		info.Network, info.ChainID, info.Explorer = c.network.Name, c.network.ChainID, c.network.Explorer
+	}
+	return info
+}
+
+func (c *Client) configInfo() *configResult {
+	gw := c.Gateway()
+	info := &configResult{
+		Gateway:  gw.Endpoint(),
+		Timeout:  gw.Timeout.String(),
+		Auth:     "none",
+		Keystore: c.keydir,
+		Rules:    c.rules != nil,
+	}
+	switch {
+	case gw.Auth.Token != "":
+		info.Auth = "token"
+	case gw.Auth.Username != "":
+		info.Auth = "basic"
+	}
+	if c.network != nil {
+		info.Network = c.network.Name
+	}
+	if c.signer != nil {
+		info.Signer = c.signer.URL()
+	}
+	return info
+}
+
+// crossChainStatus looks up the receipt of a cross-chain transaction sent
+// through the gateway. Transactions without receipt are pending.
+func (c *Client) crossChainStatus(hash string) (*crossChainResult, error) {
+	gw, client := c.gatewayConn()
+	params, _ := json.Marshal([]string{hash})
+	res, err := api.Call(client, &gw, "eth_getTransactionReceipt", params)
+	if err != nil {
+		return nil, err
+	}
+	var receipt *struct {
+		Status      string `json:"status"`
+		BlockNumber string `json:"blockNumber"`
+	}
+	if err := json.Unmarshal(res, &receipt); err != nil {
+		return nil, fmt.Errorf("invalid receipt of %s: %v", hash, err)
+	}
+	status := &crossChainResult{Hash: hash, Status: "pending"}
+	if receipt == nil {
+		return status, nil
+	}
+	status.BlockNumber = receipt.BlockNumber
+	if receipt.Status == "0x1" {
+		status.Status = "success"
+	} else {
+		status.Status = "failed"
+	}
+	return status, nil
+}
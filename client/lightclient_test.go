@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLightclientInfo(t *testing.T) {
+	assert := assert.New(t)
+	gw := config.Default().ApiGateway
+	gw.URL = "https://gateway.example.com/rpc"
+	gw.Auth.Token = "secret"
+	network := &config.Network{Name: "testnet", ChainID: 4242, Gateway: gw.URL, Explorer: "https://explorer.example.com"}
+	c, err := DialWithOptions(context.Background(), gw.URL, Options{
+		Gateway:    &gw,
+		HTTPClient: new(http.Client),
+		Network:    network,
+		Logger:     log.New(ioutil.Discard, log.LvlInfo, log.FormatText),
+	})
+	assert.Nil(err)
+
+	var info gatewayResult
+	assert.Nil(c.Call(&info, "lightclient_gateway"))
+	assert.Equal(gatewayResult{Endpoint: gw.URL, Online: true, Network: "testnet", ChainID: network.ChainID, Explorer: network.Explorer}, info)
+
+	// credentials are never shown
+	var conf map[string]interface{}
+	assert.Nil(c.Call(&conf, "lightclient_config"))
+	assert.Equal("token", conf["auth"])
+	assert.Equal("testnet", conf["network"])
+	body, _ := json.Marshal(conf)
+	assert.NotContains(string(body), "secret")
+}
+
+func TestCrossChainStatus(t *testing.T) {
+	assert := assert.New(t)
+	receipts := map[string]string{
+		"0x01": `null`,
+		"0x02": `{"status":"0x1","blockNumber":"0x10"}`,
+		"0x03": `{"status":"0x0","blockNumber":"0x11"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []string `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + receipts[req.Params[0]] + `}`))
+	}))
+	defer server.Close()
+
+	gw := config.Default().ApiGateway
+	gw.URL = server.URL
+	c, err := DialWithOptions(context.Background(), gw.URL, Options{Gateway: &gw})
+	assert.Nil(err)
+
+	tests := []struct {
+		hash string
+		want crossChainResult
+	}{
+		{"0x01", crossChainResult{Hash: "0x01", Status: "pending"}},
+		{"0x02", crossChainResult{Hash: "0x02", Status: "success", BlockNumber: "0x10"}},
+		{"0x03", crossChainResult{Hash: "0x03", Status: "failed", BlockNumber: "0x11"}},
+	}
+	for _, test := range tests {
+		var status crossChainResult
+		assert.Nil(c.Call(&status, "crosschain_status", test.hash))
+		assert.Equal(test.want, status)
+	}
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/DSiSc/astraia/api"
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
+	"github.com/DSiSc/astraia/secrets"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	wutils "github.com/DSiSc/wallet/utils"
+)
+
+var (
+	errNoKeystore = errors.New("no keystore configured")
+	errNoSigner   = errors.New("no signer configured")
+	errNoSender   = errors.New("transaction has no sender")
+)
+
+// Options configure a client created by DialWithOptions. Nothing besides what
+// the options ask for is created: the client neither reads a config file nor
+// opens a keystore of its own.
+type Options struct {
+	// Gateway is the api gateway the local handlers talk to, the gateway of
+	// config.Default if nil.
+	Gateway *config.ApiGatewayConfig
+
+	// HTTPClient carries the requests to the gateway. If nil a client with
+	// the TLS settings of Gateway is created.
+	HTTPClient *http.Client
+
+	// KeystoreDir is the keystore of the local account handlers, which fail
+	// if it is empty. The mnemonic wallet is kept next to it.
+	KeystoreDir string
+	// ScryptN and ScryptP encrypt new keys, the standard parameters if 0.
+	ScryptN, ScryptP int
+
+	// Logger receives the records of the client, log.Root() if nil.
+	Logger *log.Logger
+
+	// Signer signs the transactions of the local handlers, the keystore if nil.
+	Signer Signer
+
+	// Network is the network in use, passed to the signing rules and
+	// external signers. Nil if none is selected.
+	Network *config.Network
+
+	// Passwords provides the passwords callers of the unlock and signing
+	// handlers leave empty. Without it empty passwords are used as given.
+	Passwords secrets.Provider
+}
+
+// DialWithOptions creates a new RPC client for the given http or https URL
+// configured by opts.
+//
+// The context is used to cancel or time out the initial connection establishment. It does
+// not affect subsequent interactions with the client.
+func DialWithOptions(ctx context.Context, rawurl string, opts Options) (*Client, error) {
+	if opts.Gateway == nil {
+		opts.Gateway = &config.Default().ApiGateway
+	}
+	if err := opts.Gateway.Validate(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("no known transport for URL scheme %q", u.Scheme)
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		if client, err = api.NewHTTPClient(opts.Gateway); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+	api.SetAuthHeader(req.Header, opts.Gateway)
+
+	return newClient(ctx, &opts, func(context.Context) (ServerCodec, error) {
+		return &httpConn{client: client, req: req, closed: make(chan interface{})}, nil
+	})
+}
+
+// configOptions returns the options of the clients created by Dial and
+// friends: the gateway of cfg and the keystore of the wallet default data
+// directory.
+func configOptions(cfg *config.Config, client *http.Client) (Options, error) {
+	scryptN, scryptP, keydir, err := wutils.AccountConfig(keystore.KeyStoreScheme)
+	if err != nil {
+		return Options{}, fmt.Errorf("keystore config: %v", err)
+	}
+	return Options{
+		Gateway:     &cfg.ApiGateway,
+		HTTPClient:  client,
+		KeystoreDir: keydir,
+		ScryptN:     scryptN,
+		ScryptP:     scryptP,
+	}, nil
+}
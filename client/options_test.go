@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
+	ctypes "github.com/DSiSc/craft/types"
+	"github.com/DSiSc/crypto-suite/crypto"
+	"github.com/DSiSc/crypto-suite/rlp"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	wtypes "github.com/DSiSc/wallet/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialWithOptions(t *testing.T) {
+	assert := assert.New(t)
+	gw := config.Default().ApiGateway
+	gw.URL = "https://gateway.example.com/rpc"
+	logger := log.New(ioutil.Discard, log.LvlInfo, log.FormatText)
+	c, err := DialWithOptions(context.Background(), gw.URL, Options{
+		Gateway:    &gw,
+		HTTPClient: new(http.Client),
+		Logger:     logger,
+	})
+	assert.Nil(err)
+	assert.Equal(gw.URL, c.Gateway().Endpoint())
+	assert.Equal(logger, c.log)
+	assert.NotNil(c.getWeb3())
+
+	// without keystore and signer the local handlers cannot sign
+	assert.Nil(c.keystore)
+	_, err = c.signTx(nil, "")
+	assert.Equal(errNoSigner, err)
+	assert.Equal(errNoKeystore, c.openWallet(KeystoreSignerURL, ""))
+}
+
+func TestDialWithOptionsKeystore(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "astraia-options")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	c, err := DialWithOptions(context.Background(), "http://127.0.0.1:47768", Options{KeystoreDir: dir})
+	assert.Nil(err)
+	assert.Equal(config.Default().ApiGateway, c.Gateway())
+	assert.NotNil(c.keystore)
+	assert.Equal(KeystoreSignerURL, c.signer.URL())
+	assert.Equal(log.Root(), c.log)
+}
+
+func TestDialWithOptionsInvalid(t *testing.T) {
+	assert := assert.New(t)
+	_, err := DialWithOptions(context.Background(), "ws://127.0.0.1:47768", Options{})
+	assert.NotNil(err)
+
+	gw := config.Default().ApiGateway
+	gw.URL = "ftp://gateway.example.com"
+	_, err = DialWithOptions(context.Background(), "http://127.0.0.1:47768", Options{Gateway: &gw})
+	assert.NotNil(err)
+}
+
+// recordingSigner records the transactions it signs and their chain IDs.
+type recordingSigner struct {
+	Signer
+	chainIDs []uint64
+	signed   [][]byte
+}
+
+func (s *recordingSigner) SignTx(tx *ctypes.Transaction, password string, chainID uint64) ([]byte, error) {
+	raw, err := s.Signer.SignTx(tx, password, chainID)
+	s.chainIDs, s.signed = append(s.chainIDs, chainID), append(s.signed, raw)
+	return raw, err
+}
+
+func TestSignCrossTransactionChainID(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "astraia-options")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	key, err := crypto.GenerateKey()
+	assert.Nil(err)
+	account, err := ks.ImportECDSA(key, "123")
+	assert.Nil(err)
+	from := fmt.Sprintf("0x%x", account.Address)
+
+	network := &config.Network{Name: "testnet", ChainID: 4242, Gateway: "http://127.0.0.1:47768"}
+	c, err := DialWithOptions(context.Background(), network.Gateway, Options{
+		Network: network,
+		Logger:  log.New(ioutil.Discard, log.LvlInfo, log.FormatText),
+	})
+	assert.Nil(err)
+	signer := &recordingSigner{Signer: NewKeystoreSigner(ks)}
+	c.signer = signer
+
+	tx := Tx{"from": from, "to": from, "nonce": "1", "gas": "0", "gasPrice": "0", "value": "1000"}
+	var result string
+	assert.Nil(c.Call(&result, "personal_signCrossTransaction", tx, from, "chainB", "123"))
+	assert.True(strings.HasPrefix(result, "0x"), result)
+
+	// the inner transaction runs on the target chain and is signed without
+	// replay protection, the outer one for the network in use
+	if !assert.Equal([]uint64{0, network.ChainID}, signer.chainIDs) {
+		return
+	}
+	var inner, outer ctypes.Transaction
+	assert.Nil(rlp.DecodeBytes(signer.signed[0], &inner))
+	sender, err := wtypes.Sender(wtypes.HomesteadSigner{}, &inner)
+	assert.Nil(err)
+	assert.Equal(from, fmt.Sprintf("0x%x", sender))
+	assert.Nil(rlp.DecodeBytes(signer.signed[1], &outer))
+	sender, err = wtypes.Sender(wtypes.NewEIP155Signer(new(big.Int).SetUint64(network.ChainID)), &outer)
+	assert.Nil(err)
+	assert.Equal(from, fmt.Sprintf("0x%x", sender))
+}
@@ -0,0 +1,524 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/rules"
+	"github.com/DSiSc/astraia/secrets"
+	ctypes "github.com/DSiSc/craft/types"
+	"github.com/DSiSc/crypto-suite/rlp"
+	"github.com/DSiSc/wallet/accounts"
+	"github.com/DSiSc/wallet/accounts/keystore"
+)
+
+const (
+	// KeystoreSignerURL selects the file keystore as signing backend.
+	KeystoreSignerURL = "keystore://"
+	// ExternalSignerScheme prefixes the command line of an external signer
+	// process, e.g. extsigner:///usr/local/bin/signer --device 0.
+	ExternalSignerScheme = "extsigner://"
+)
+
+var (
+	ErrSignerPINNeeded        = errors.New("signer PIN needed")
+	ErrSignerPassphraseNeeded = errors.New("signer passphrase needed")
+	ErrSignerClosed           = errors.New("signer is closed")
+	errUnknownSignerURL       = errors.New("unknown wallet url, expected keystore:// or extsigner://<command>")
+)
+
+// Signer is a wallet backend able to sign transactions for the local handlers.
+type Signer interface {
+	// URL identifies the signer, it is the value accepted by personal.openWallet.
+	URL() string
+
+	// Open unlocks the backend, e.g. with the PIN or passphrase of a device.
+	Open(passphrase string) error
+
+	// Accounts returns the hex encoded addresses the signer holds keys for.
+	Accounts() ([]string, error)
+
+	// SignTx signs tx for chainID with the key of its sender, without replay
+	// protection if chainID is 0, and returns the RLP encoding of the signed
+	// transaction. The password unlocks the key if the backend needs one.
+	SignTx(tx *ctypes.Transaction, password string, chainID uint64) ([]byte, error)
+
+	// Close releases the resources held by the signer.
+	Close() error
+}
+
+// NewSigner creates the signer identified by url, see KeystoreSignerURL and
+// ExternalSignerScheme.
+func NewSigner(url string, ks *keystore.KeyStore) (Signer, error) {
+	switch {
+	case url == "" || url == KeystoreSignerURL || url == "keystore":
+		if ks == nil {
+			return nil, errNoKeystore
+		}
+		return NewKeystoreSigner(ks), nil
+	case strings.HasPrefix(url, ExternalSignerScheme):
+		args := strings.Fields(strings.TrimPrefix(url, ExternalSignerScheme))
+		if len(args) == 0 {
+			return nil, errUnknownSignerURL
+		}
+		return NewExternalSigner(args[0], args[1:]...)
+	default:
+		return nil, errUnknownSignerURL
+	}
+}
+
+// keystoreSigner signs with the encrypted key files of the local keystore.
+type keystoreSigner struct {
+	keystore *keystore.KeyStore
+}
+
+// NewKeystoreSigner creates a signer backed by the file keystore.
+func NewKeystoreSigner(ks *keystore.KeyStore) Signer {
+	return &keystoreSigner{keystore: ks}
+}
+
+func (s *keystoreSigner) URL() string { return KeystoreSignerURL }
+
+// Open is a no-op, keystore keys are unlocked per signature by their password.
+func (s *keystoreSigner) Open(passphrase string) error { return nil }
+
+func (s *keystoreSigner) Accounts() ([]string, error) {
+	var addrs []string
+	if s.keystore == nil {
+		return addrs, nil
+	}
+	for _, account := range s.keystore.Accounts() {
+		addrs = append(addrs, fmt.Sprintf("0x%x", account.Address))
+	}
+	return addrs, nil
+}
+
+func (s *keystoreSigner) SignTx(tx *ctypes.Transaction, password string, chainID uint64) ([]byte, error) {
+	if s.keystore == nil {
+		return nil, errNoKeystore
+	}
+	if tx.Data.From == nil {
+		return nil, errNoSender
+	}
+	account, err := s.keystore.Find(accounts.Account{Address: *tx.Data.From})
+	if err != nil {
+		return nil, fmt.Errorf("account 0x%x: %v", *tx.Data.From, err)
+	}
+	var id *big.Int
+	if chainID != 0 {
+		id = new(big.Int).SetUint64(chainID)
+	}
+	signed, err := s.keystore.SignTxWithPassphrase(account, password, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+func (s *keystoreSigner) Close() error { return nil }
+
+// SignTxArgs is the transaction sent to an external signer. Raw holds the RLP
+// encoding of the unsigned transaction the other fields are decoded from.
+type SignTxArgs struct {
+	From     string `json:"from"`
+	To       string `json:"to,omitempty"`
+	Nonce    string `json:"nonce"`
+	Gas      string `json:"gas"`
+	GasPrice string `json:"gasPrice"`
+	Value    string `json:"value"`
+	Input    string `json:"input"`
+	ChainID  string `json:"chainId,omitempty"` // empty to sign without replay protection
+	Raw      string `json:"raw"`
+}
+
+// Error codes of the external signer protocol, also returned by
+// personal_openWallet, asking the user for input before reopening the signer.
+const (
+	SignerPINNeededCode        = -32010
+	SignerPassphraseNeededCode = -32011
+)
+
+// externalSignerTimeout bounds the wait for a response of an external signer,
+// leaving time to confirm a signature on a device.
+const externalSignerTimeout = 2 * time.Minute
+
+// externalSigner talks to a signer process over its standard input and output,
+// exchanging one JSON-RPC message per line. The process must implement:
+//
+//	account_open(passphrase)  -> true, or an error with the code
+//	                             SignerPINNeededCode or SignerPassphraseNeededCode
+//	account_list()            -> ["0x..."]
+//	account_signTransaction(SignTxArgs, password) -> "0x<rlp signed tx>"
+//
+// The process is expected to exit once its standard input is closed.
+type externalSigner struct {
+	url     string
+	cmd     *exec.Cmd
+	timeout time.Duration // of a response
+
+	mu      sync.Mutex // serializes the calls
+	stdin   io.WriteCloser
+	replies chan []byte // lines of the standard output, closed at its end
+	readErr error       // ends the standard output, set before replies is closed
+	counter uint32
+
+	closing   chan struct{} // closed by Close, interrupting a pending call
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewExternalSigner starts the signer process and returns a signer speaking
+// to it. The signer still needs to be opened before signing.
+func NewExternalSigner(command string, args ...string) (Signer, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start external signer: %v", err)
+	}
+	s := &externalSigner{
+		url:     ExternalSignerScheme + strings.Join(append([]string{command}, args...), " "),
+		cmd:     cmd,
+		timeout: externalSignerTimeout,
+		stdin:   stdin,
+		replies: make(chan []byte),
+		closing: make(chan struct{}),
+	}
+	go s.read(bufio.NewReader(stdout))
+	return s, nil
+}
+
+// read passes the lines of the standard output of the process to the calls.
+func (s *externalSigner) read(stdout *bufio.Reader) {
+	defer close(s.replies)
+	for {
+		line, err := stdout.ReadBytes('\n')
+		if err != nil {
+			s.readErr = err
+			return
+		}
+		select {
+		case s.replies <- line:
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+func (s *externalSigner) URL() string { return s.url }
+
+func (s *externalSigner) Open(passphrase string) error {
+	var ok bool
+	if err := s.call(&ok, "account_open", passphrase); err != nil {
+		if rpcErr, isRPC := err.(Error); isRPC {
+			switch rpcErr.ErrorCode() {
+			case SignerPINNeededCode:
+				return ErrSignerPINNeeded
+			case SignerPassphraseNeededCode:
+				return ErrSignerPassphraseNeeded
+			}
+		}
+		return err
+	}
+	if !ok {
+		return errors.New("external signer refused to open")
+	}
+	return nil
+}
+
+func (s *externalSigner) Accounts() ([]string, error) {
+	var addrs []string
+	err := s.call(&addrs, "account_list")
+	return addrs, err
+}
+
+func (s *externalSigner) SignTx(tx *ctypes.Transaction, password string, chainID uint64) ([]byte, error) {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	args := SignTxArgs{
+		Nonce:    fmt.Sprintf("0x%x", tx.Data.AccountNonce),
+		Gas:      fmt.Sprintf("0x%x", tx.Data.GasLimit),
+		GasPrice: "0x0",
+		Value:    "0x0",
+		Input:    fmt.Sprintf("0x%x", tx.Data.Payload),
+		Raw:      fmt.Sprintf("0x%x", raw),
+	}
+	if tx.Data.From != nil {
+		args.From = fmt.Sprintf("0x%x", *tx.Data.From)
+	}
+	if tx.Data.Recipient != nil {
+		args.To = fmt.Sprintf("0x%x", *tx.Data.Recipient)
+	}
+	if tx.Data.Price != nil {
+		args.GasPrice = fmt.Sprintf("0x%x", tx.Data.Price)
+	}
+	if tx.Data.Amount != nil {
+		args.Value = fmt.Sprintf("0x%x", tx.Data.Amount)
+	}
+	if chainID != 0 {
+		args.ChainID = fmt.Sprintf("0x%x", chainID)
+	}
+
+	var signed string
+	if err := s.call(&signed, "account_signTransaction", args, password); err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(signed, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("external signer returned invalid transaction: %v", err)
+	}
+	return data, nil
+}
+
+// Close closes the standard input of the process, interrupting a pending
+// call, and waits for the process to exit, killing it if it does not.
+func (s *externalSigner) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		s.stdin.Close()
+		exited := make(chan error, 1)
+		go func() { exited <- s.cmd.Wait() }()
+		select {
+		case s.closeErr = <-exited:
+		case <-time.After(5 * time.Second):
+			s.cmd.Process.Kill()
+			s.closeErr = <-exited
+		}
+	})
+	return s.closeErr
+}
+
+// call sends a request to the signer process and waits for its response, at
+// most s.timeout. Requests are serialized, the protocol has no notion of
+// concurrency.
+func (s *externalSigner) call(result interface{}, method string, args ...interface{}) error {
+	select {
+	case <-s.closing:
+		return ErrSignerClosed
+	default:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counter++
+	req := &jsonrpcMessage{Version: vsn, ID: json.RawMessage(fmt.Sprintf("%d", s.counter)), Method: method}
+	params, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	req.Params = params
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.stdin.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("external signer: %v", err)
+	}
+	timeout := time.NewTimer(s.timeout)
+	defer timeout.Stop()
+	for {
+		var reply []byte
+		select {
+		case r, ok := <-s.replies:
+			if !ok {
+				return fmt.Errorf("external signer: %v", s.readErr)
+			}
+			reply = r
+		case <-timeout.C:
+			return fmt.Errorf("external signer: no response to %s within %v", method, s.timeout)
+		case <-s.closing:
+			return ErrSignerClosed
+		}
+		var resp jsonrpcMessage
+		if err := json.Unmarshal(reply, &resp); err != nil {
+			return fmt.Errorf("external signer sent invalid response: %v", err)
+		}
+		if string(resp.ID) != string(req.ID) {
+			// late response to a request which timed out
+			continue
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if len(resp.Result) == 0 {
+			return ErrNoResult
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+// openWallet opens the signer identified by url and makes it the backend of
+// the local signing handlers. A signer asking for a PIN or passphrase is kept
+// running, so that the console can prompt the user and reopen it.
+func (c *Client) openWallet(url, passphrase string) error {
+	signer := c.signer
+	if signer == nil || signer.URL() != url {
+		if c.pendingSigner != nil && c.pendingSigner.URL() == url {
+			signer = c.pendingSigner
+		} else {
+			s, err := NewSigner(url, c.keystore)
+			if err != nil {
+				return err
+			}
+			if c.pendingSigner != nil {
+				c.pendingSigner.Close()
+			}
+			signer, c.pendingSigner = s, nil
+		}
+	}
+	if err := signer.Open(passphrase); err != nil {
+		if signer != c.signer {
+			if needsInput(err) {
+				c.pendingSigner = signer
+			} else {
+				signer.Close()
+				c.pendingSigner = nil
+			}
+		}
+		return err
+	}
+	if signer != c.signer {
+		if c.signer != nil {
+			c.signer.Close()
+		}
+		c.signer, c.pendingSigner = signer, nil
+	}
+	return nil
+}
+
+// signTx signs tx for the network in use with the current signer. An empty
+// password is looked up for the sender of tx.
+func (c *Client) signTx(tx *ctypes.Transaction, password string) ([]byte, error) {
+	return c.signTxFor(tx, password, c.chainID())
+}
+
+// signTxFor signs tx for chainID, without replay protection if it is 0.
+func (c *Client) signTxFor(tx *ctypes.Transaction, password string, chainID uint64) ([]byte, error) {
+	if c.signer == nil {
+		return nil, errNoSigner
+	}
+	if tx.Data.From != nil {
+		var err error
+		if password, err = c.password(fmt.Sprintf("0x%x", *tx.Data.From), password); err != nil {
+			return nil, err
+		}
+	}
+	return c.signer.SignTx(tx, password, chainID)
+}
+
+// Accounts returns the addresses of the keystore, none if no keystore is
+// configured.
+func (c *Client) Accounts() []string {
+	if c.keystore == nil {
+		return nil
+	}
+	addrs, _ := NewKeystoreSigner(c.keystore).Accounts()
+	return addrs
+}
+
+// SetPasswords sets the provider of the passwords callers leave empty.
+func (c *Client) SetPasswords(p secrets.Provider) {
+	c.passwords = p
+}
+
+// HasPassword reports whether a password source may provide the password of
+// account, so that callers need not ask the user for it.
+func (c *Client) HasPassword(account string) bool {
+	if c.passwords == nil {
+		return false
+	}
+	if store, ok := c.passwords.(interface{ Has(string) bool }); ok {
+		return store.Has(account)
+	}
+	return true
+}
+
+// password returns the password given by the caller, or if it is empty the
+// one of the account's password source.
+func (c *Client) password(account, given string) (string, error) {
+	if given != "" || c.passwords == nil {
+		return given, nil
+	}
+	password, err := c.passwords.Password(account)
+	if err == secrets.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("password of %s: %v", account, err)
+	}
+	return password, nil
+}
+
+// needsInput reports whether a signer failed to open for lack of user input.
+func needsInput(err error) bool {
+	return err == ErrSignerPINNeeded || err == ErrSignerPassphraseNeeded
+}
+
+// openWalletErrorCode returns the JSON-RPC error code of a personal_openWallet
+// failure, telling the caller which input the signer needs.
+func openWalletErrorCode(err error) int {
+	switch err {
+	case ErrSignerPINNeeded:
+		return SignerPINNeededCode
+	case ErrSignerPassphraseNeeded:
+		return SignerPassphraseNeededCode
+	default:
+		return defaultErrorCode
+	}
+}
+
+// SetRules makes the local signing handlers ask e for approval before signing.
+// A nil engine approves every request.
+func (c *Client) SetRules(e *rules.Engine) {
+	c.rules = e
+}
+
+// Network returns the network in use, nil if none is selected.
+func (c *Client) Network() *config.Network {
+	return c.network
+}
+
+// chainID returns the chain ID of the network in use, 0 if none.
+func (c *Client) chainID() uint64 {
+	if c.network == nil {
+		return 0
+	}
+	return c.network.ChainID
+}
+
+// approveTx asks the rules engine, if any, to approve signing tx.
+func (c *Client) approveTx(method string, tx *ctypes.Transaction, cross *rules.CrossChain) error {
+	if c.rules == nil {
+		return nil
+	}
+	req := rules.NewRequest(method, tx, cross)
+	if c.network != nil {
+		req.Network, req.ChainID = c.network.Name, c.network.ChainID
+	}
+	if cross != nil && cross.Inner {
+		// signed without replay protection, see personal_signCrossTransaction
+		req.ChainID = 0
+	}
+	return c.rules.Approve(req)
+}
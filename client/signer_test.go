@@ -0,0 +1,264 @@
+package rpc_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DSiSc/astraia/client"
+	"github.com/DSiSc/astraia/rules"
+	ctypes "github.com/DSiSc/craft/types"
+	"github.com/DSiSc/crypto-suite/crypto"
+	"github.com/DSiSc/crypto-suite/rlp"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	wtypes "github.com/DSiSc/wallet/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	stubSignerEnv  = "ASTRAIA_STUB_SIGNER"
+	stubSignerPIN  = "1234"
+	stubSignerAddr = "0x1b192c4e353dc40871066023bf37fc632f1695d4"
+)
+
+// TestStubSigner is not a real test, it is the external signer process started
+// by TestExternalSigner.
+func TestStubSigner(t *testing.T) {
+	mode := os.Getenv(stubSignerEnv)
+	if mode == "" {
+		return
+	}
+	type request struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req request
+		json.Unmarshal(scanner.Bytes(), &req)
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "account_open":
+			var pin string
+			json.Unmarshal(req.Params[0], &pin)
+			if pin != stubSignerPIN {
+				resp["error"] = map[string]interface{}{"code": rpc.SignerPINNeededCode, "message": "PIN needed"}
+			} else {
+				resp["result"] = true
+			}
+		case "account_list":
+			if mode == "hang" {
+				continue
+			}
+			resp["result"] = []string{stubSignerAddr}
+		case "account_signTransaction":
+			// echo the unsigned transaction back so the caller can check it
+			var args rpc.SignTxArgs
+			json.Unmarshal(req.Params[0], &args)
+			resp["result"] = args.Raw
+		default:
+			resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		out, _ := json.Marshal(resp)
+		fmt.Fprintln(os.Stdout, string(out))
+	}
+	os.Exit(0)
+}
+
+func TestExternalSigner(t *testing.T) {
+	os.Setenv(stubSignerEnv, "1")
+	defer os.Unsetenv(stubSignerEnv)
+
+	signer, err := rpc.NewSigner(rpc.ExternalSignerScheme+os.Args[0]+" -test.run=TestStubSigner", nil)
+	assert.Nil(t, err)
+	defer signer.Close()
+
+	err = signer.Open("")
+	assert.Equal(t, rpc.ErrSignerPINNeeded, err)
+	assert.Nil(t, signer.Open(stubSignerPIN))
+
+	accounts, err := signer.Accounts()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{stubSignerAddr}, accounts)
+
+	tx, err := rpc.TxToTransaction(rpc.Tx{
+		"from":     stubSignerAddr,
+		"to":       stubSignerAddr,
+		"nonce":    "1",
+		"gas":      "0",
+		"gasPrice": "0",
+		"value":    "1000",
+	})
+	assert.Nil(t, err)
+	signed, err := signer.SignTx(&tx, "", 0)
+	assert.Nil(t, err)
+	expect, _ := rlp.EncodeToBytes(&tx)
+	assert.Equal(t, expect, signed)
+
+	assert.Nil(t, signer.Close())
+	_, err = signer.Accounts()
+	assert.Equal(t, rpc.ErrSignerClosed, err)
+}
+
+func TestExternalSignerClose(t *testing.T) {
+	os.Setenv(stubSignerEnv, "hang")
+	defer os.Unsetenv(stubSignerEnv)
+
+	signer, err := rpc.NewSigner(rpc.ExternalSignerScheme+os.Args[0]+" -test.run=TestStubSigner", nil)
+	assert.Nil(t, err)
+
+	// closing interrupts a call the signer never answers
+	called := make(chan error, 1)
+	go func() {
+		_, err := signer.Accounts()
+		called <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	closed := make(chan error, 1)
+	go func() { closed <- signer.Close() }()
+	select {
+	case err := <-called:
+		assert.Equal(t, rpc.ErrSignerClosed, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("pending call not interrupted by Close")
+	}
+	select {
+	case err := <-closed:
+		assert.Nil(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close blocked")
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+
+	signer, err := rpc.NewSigner(rpc.KeystoreSignerURL, ks)
+	assert.Nil(t, err)
+	assert.Equal(t, rpc.KeystoreSignerURL, signer.URL())
+
+	_, err = rpc.NewSigner(rpc.KeystoreSignerURL, nil)
+	assert.NotNil(t, err)
+	_, err = rpc.NewSigner("usb://ledger", ks)
+	assert.NotNil(t, err)
+}
+
+// newTestKeystore creates the keystore directory keystore in a temporary
+// directory, holding one account protected by the password 123.
+func newTestKeystore(t *testing.T) (dir string, ks *keystore.KeyStore, account string) {
+	dir = tmpdir(t)
+	ks = keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ks.ImportECDSA(key, "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, ks, fmt.Sprintf("0x%x", a.Address)
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	const chainID = 1337
+	dir, ks, from := newTestKeystore(t)
+	defer os.RemoveAll(dir)
+
+	signer := rpc.NewKeystoreSigner(ks)
+	accounts, err := signer.Accounts()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{from}, accounts)
+
+	tx, err := rpc.TxToTransaction(rpc.Tx{
+		"from":     from,
+		"to":       stubSignerAddr,
+		"nonce":    "1",
+		"gas":      "0",
+		"gasPrice": "0",
+		"value":    "1000",
+	})
+	assert.Nil(t, err)
+	_, err = signer.SignTx(&tx, "wrong", chainID)
+	assert.NotNil(t, err)
+
+	raw, err := signer.SignTx(&tx, "123", chainID)
+	assert.Nil(t, err)
+	var signed ctypes.Transaction
+	assert.Nil(t, rlp.DecodeBytes(raw, &signed))
+	sender, err := wtypes.Sender(wtypes.NewEIP155Signer(big.NewInt(chainID)), &signed)
+	assert.Nil(t, err)
+	assert.Equal(t, from, fmt.Sprintf("0x%x", sender))
+
+	// accounts of other keystores cannot sign
+	otherDir := tmpdir(t)
+	defer os.RemoveAll(otherDir)
+	other := rpc.NewKeystoreSigner(keystore.NewKeyStore(otherDir, keystore.LightScryptN, keystore.LightScryptP))
+	_, err = other.SignTx(&tx, "123", chainID)
+	assert.NotNil(t, err)
+}
+
+func TestCrossChainRules(t *testing.T) {
+	dir, _, from := newTestKeystore(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "rules.js")
+	rejectInner := `function ApproveTx(req) { return req.crossChain && req.crossChain.inner ? "Reject" : "Approve"; }`
+	assert.Nil(t, ioutil.WriteFile(file, []byte(rejectInner), 0600))
+	engine, err := rules.NewEngine(file, nil, nil)
+	assert.Nil(t, err)
+	defer engine.Stop()
+
+	client, err := rpc.DialWithOptions(context.Background(), "http://127.0.0.1:47768", rpc.Options{
+		KeystoreDir: filepath.Join(dir, "keystore"),
+		ScryptN:     keystore.LightScryptN,
+		ScryptP:     keystore.LightScryptP,
+	})
+	assert.Nil(t, err)
+	client.SetRules(engine)
+
+	tx := rpc.Tx{"from": from, "to": stubSignerAddr, "nonce": "1", "gas": "0", "gasPrice": "0", "value": "1000"}
+	var result string
+	assert.Nil(t, client.Call(&result, "personal_signCrossTransaction", tx, stubSignerAddr, "chainB", "123"))
+	assert.Contains(t, result, "rejected by rules")
+
+	// the query transaction has no inner one
+	assert.Nil(t, client.Call(&result, "personal_signCrossQueryTransaction", tx, stubSignerAddr, "chainB", "123"))
+	assert.True(t, strings.HasPrefix(result, "0x"), result)
+}
+
+func TestUseKeystore(t *testing.T) {
+	dir, _, from := newTestKeystore(t)
+	defer os.RemoveAll(dir)
+
+	// a client without keystore signs once given one
+	client, err := rpc.DialWithOptions(context.Background(), "http://127.0.0.1:47768", rpc.Options{})
+	assert.Nil(t, err)
+	client.UseKeystore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	assert.Equal(t, []string{from}, client.Accounts())
+
+	tx := rpc.Tx{"from": from, "to": stubSignerAddr, "nonce": "1", "gas": "0", "gasPrice": "0", "value": "1000"}
+	var result string
+	assert.Nil(t, client.Call(&result, "personal_signTransaction", tx, "123"))
+	raw, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if !assert.Nil(t, err, result) {
+		return
+	}
+	var signed ctypes.Transaction
+	assert.Nil(t, rlp.DecodeBytes(raw, &signed))
+	sender, err := wtypes.Sender(wtypes.HomesteadSigner{}, &signed)
+	assert.Nil(t, err)
+	assert.Equal(t, from, fmt.Sprintf("0x%x", sender))
+}
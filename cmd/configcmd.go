@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/utils"
+	wutils "github.com/DSiSc/wallet/utils"
+	"github.com/urfave/cli"
+)
+
+var (
+	configForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Overwrite an existing config file",
+	}
+	configEnvFlag = cli.BoolFlag{
+		Name:  "env",
+		Usage: "Print the environment variables overriding the settings instead",
+	}
+
+	configCommand = cli.Command{
+		Name:     "config",
+		Usage:    "Inspect and edit the configuration",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+The configuration is read from --config, $ASTRAIA_CONFIG or light_client.yaml
+in ~/.astraia, with the settings of --profile applied on top.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "show",
+				Usage:  "Print the effective configuration and the source of each value",
+				Action: utils.MigrateFlags(configShow),
+				Flags:  append(append([]cli.Flag{configEnvFlag}, nodeFlags...), configFlags...),
+				Description: `
+Every setting can be overridden by an environment variable named after its
+key, e.g. ASTRAIA_APIGATEWAY_URL for apigateway.url, which takes precedence
+over the config file and its profiles. config show --env lists them.`,
+			},
+			{
+				Name:      "set",
+				Usage:     "Store a setting in the config file",
+				Action:    utils.MigrateFlags(configSet),
+				Flags:     configFlags,
+				ArgsUsage: "<key> <value>",
+				Description: `
+    astraia config set apigateway.url https://gateway.example.com/rpc
+
+Stores the value in the config file in use, or in ~/.astraia/light_client.yaml
+if there is none, below the --profile profile if given. The file is left
+unchanged if the result is invalid.`,
+			},
+			{
+				Name:   "init",
+				Usage:  "Write a commented default config file to ~/.astraia",
+				Action: utils.MigrateFlags(configInit),
+				Flags:  []cli.Flag{utils.ConfigFileFlag, configForceFlag},
+			},
+			{
+				Name:   "validate",
+				Usage:  "Check the config file and all of its profiles",
+				Action: utils.MigrateFlags(configValidate),
+				Flags:  configFlags,
+			},
+		},
+	}
+)
+
+func configShow(ctx *cli.Context) error {
+	if ctx.Bool(configEnvFlag.Name) {
+		return configShowEnv()
+	}
+	// the network and keystore flags take precedence over the config file
+	flags := []struct {
+		key  string
+		flag cli.Flag
+	}{
+		{config.NetworkKey, utils.NetworkFlag},
+		{config.KeystoreDataDir, wutils.DataDirFlag},
+		{config.KeystoreDir, wutils.KeyStoreDirFlag},
+		{config.KeystoreLightKDF, wutils.LightKDFFlag},
+	}
+	var overrides []config.Override
+	for _, f := range flags {
+		if name := f.flag.GetName(); ctx.GlobalIsSet(name) {
+			overrides = append(overrides, config.Override{
+				Key:    f.key,
+				Value:  ctx.GlobalString(name),
+				Source: config.SourceFlag + " --" + name,
+			})
+		}
+	}
+	settings, conf, err := config.Describe(ctx.GlobalString(utils.ConfigFileFlag.Name), ctx.GlobalString(utils.ProfileFlag.Name), overrides...)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+
+	file := conf.File
+	if file == "" {
+		file = "none, using defaults"
+	}
+	fmt.Printf("Config file: %s\n", file)
+	if conf.Profile != "" {
+		fmt.Printf("Profile:     %s\n", conf.Profile)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, setting := range settings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source)
+	}
+	return w.Flush()
+}
+
+// configShowEnv prints the reference of the environment variables.
+func configShowEnv() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tTYPE\tDEFAULT\tDESCRIPTION")
+	for _, v := range config.EnvVars() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Name, v.Type, v.Default, v.Usage)
+	}
+	return w.Flush()
+}
+
+func configSet(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires the key and the value as arguments.")
+	}
+	file, err := config.Set(ctx.GlobalString(utils.ConfigFileFlag.Name), ctx.GlobalString(utils.ProfileFlag.Name), ctx.Args()[0], ctx.Args()[1])
+	if err != nil {
+		utils.Fatalf("Failed to set %s: %v", ctx.Args()[0], err)
+	}
+	fmt.Printf("Set %s in %s\n", ctx.Args()[0], file)
+	return nil
+}
+
+func configInit(ctx *cli.Context) error {
+	path := ctx.GlobalString(utils.ConfigFileFlag.Name)
+	if path == "" {
+		var err error
+		if path, err = config.HomeFile(); err != nil {
+			utils.Fatalf("Cannot determine home directory: %v", err)
+		}
+	}
+	if ctx.Bool(configForceFlag.Name) {
+		os.Remove(path)
+	}
+	if err := config.WriteDefault(path); err != nil {
+		if os.IsExist(err) {
+			utils.Fatalf("Config file %s already exists, use --force to overwrite it", path)
+		}
+		utils.Fatalf("Failed to write config file: %v", err)
+	}
+	fmt.Printf("Config file written to %s\n", path)
+	return nil
+}
+
+func configValidate(ctx *cli.Context) error {
+	file := ctx.GlobalString(utils.ConfigFileFlag.Name)
+	profiles := []string{ctx.GlobalString(utils.ProfileFlag.Name)}
+	if profiles[0] == "" {
+		all, err := config.Profiles(file)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		profiles = append(profiles, all...)
+	}
+
+	var conf *config.Config
+	for _, profile := range profiles {
+		var err error
+		if conf, err = config.Load(file, profile); err != nil {
+			utils.Fatalf("%v", err)
+		}
+	}
+	if conf.File == "" {
+		fmt.Println("No config file found, the defaults are valid")
+		return nil
+	}
+	fmt.Printf("Config file %s is valid\n", conf.File)
+	return nil
+}
@@ -17,24 +17,28 @@
 package main
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"github.com/DSiSc/astraia/client"
 	"github.com/DSiSc/astraia/config"
 	"github.com/DSiSc/astraia/console"
+	"github.com/DSiSc/astraia/log"
+	"github.com/DSiSc/astraia/rules"
 	"github.com/DSiSc/astraia/utils"
 	"github.com/urfave/cli"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
 var (
-	consoleFlags = []cli.Flag{utils.JSpathFlag, utils.ExecFlag, utils.PreloadJSFlag}
+	consoleFlags = []cli.Flag{utils.JSpathFlag, utils.ExecFlag, utils.PreloadJSFlag, utils.RulesFlag, utils.AuditLogFlag, utils.OutputFlag, utils.NoColorFlag, utils.ContinueOnErrorFlag, utils.PromptFlag}
 
 	consoleCommand = cli.Command{
 		Action:   utils.MigrateFlags(remoteConsole),
 		Name:     "console",
 		Usage:    "Start an interactive JavaScript environment",
-		Flags:    append(append(append(nodeFlags, rpcFlags...), consoleFlags...), whisperFlags...),
+		Flags:    append(append(append(append(nodeFlags, configFlags...), rpcFlags...), consoleFlags...), whisperFlags...),
 		Category: "CONSOLE COMMANDS",
 		Description: `
 The Geth console is an interactive shell for the JavaScript runtime environment
@@ -86,19 +90,45 @@ func remoteConsole(ctx *cli.Context) error {
 	//}
 
 	//read config file
-	hostname := config.GetApiGatewayHostName()
-	port := config.GetApiGatewayPort()
-	endpoint := fmt.Sprintf("http://%s:/%s", hostname, port)
-
-	client, err := dialRPC(endpoint)
-	if err != nil {
-		utils.Fatalf("Unable to attach to remote geth: %v", err)
+	conf := utils.MakeConfig(ctx)
+	client := makeConsoleClient(ctx, conf)
+	// edits of the config file reconfigure the gateway of the running console
+	config.Watch(conf, func(conf *config.Config, err error) {
+		if err == nil {
+			err = utils.ApplyNetwork(ctx, conf)
+		}
+		if err != nil {
+			log.Warn("Config reload failed", "err", err)
+			return
+		}
+		if client.Gateway() == conf.ApiGateway {
+			return
+		}
+		previous, err := client.SetGateway(&conf.ApiGateway)
+		if err != nil {
+			log.Warn("Config reload failed", "err", err)
+			return
+		}
+		log.Info("Gateway switched", "from", previous, "to", conf.ApiGateway.Endpoint())
+	})
+	engine := makeRulesEngine(ctx)
+	if engine != nil {
+		client.SetRules(engine)
+		defer engine.Stop()
 	}
+	// statements piped into the console are evaluated without prompting
+	piped := !console.StdinIsTerminal()
 	config := console.Config{
 		DataDir: utils.MakeDataDir(ctx),
+		Profile: conf.Profile,
 		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
 		Client:  client,
+		Prompt:  utils.MakeConsolePrompt(ctx, conf),
 		Preload: utils.MakeConsolePreloads(ctx),
+		Output:  utils.MakeConsoleOutput(ctx),
+	}
+	if piped {
+		config.Prompter = console.NonInteractive
 	}
 
 	console, err := console.New(config)
@@ -107,8 +137,24 @@ func remoteConsole(ctx *cli.Context) error {
 	}
 	defer console.Stop(false)
 
+	// failed statements were reported by the console, exit with status 1
+	fail := func() {
+		console.Stop(false)
+		if engine != nil {
+			engine.Stop()
+		}
+		os.Exit(1)
+	}
 	if script := ctx.GlobalString(utils.ExecFlag.Name); script != "" {
-		console.Evaluate(script)
+		if err := console.Evaluate(script); err != nil {
+			fail()
+		}
+		return nil
+	}
+	if piped {
+		if err := console.Stream(os.Stdin, ctx.GlobalBool(utils.ContinueOnErrorFlag.Name)); err != nil {
+			fail()
+		}
 		return nil
 	}
 
@@ -119,10 +165,58 @@ func remoteConsole(ctx *cli.Context) error {
 	return nil
 }
 
+// makeConsoleClient creates the client of the api gateway in conf, signing with
+// the keystore and passwords selected on the command line.
+func makeConsoleClient(ctx *cli.Context, conf *config.Config) *rpc.Client {
+	keydir, scryptN, scryptP := utils.MakeKeystoreConfig(ctx, conf)
+	client, err := dialRPC(conf.ApiGateway.Endpoint(), rpc.Options{
+		Gateway:     &conf.ApiGateway,
+		KeystoreDir: keydir,
+		ScryptN:     scryptN,
+		ScryptP:     scryptP,
+		Passwords:   utils.MakePasswords(ctx, conf),
+		Network:     conf.Chain(),
+	})
+	if err != nil {
+		utils.Fatalf("Unable to attach to remote geth: %v", err)
+	}
+	return client
+}
+
+// makeRulesEngine loads the --rules file, returning nil if no rules were given.
+// Decisions are audited to --auditlog, relative paths are resolved in the data
+// directory. The user confirms requests only if standard input is a terminal.
+func makeRulesEngine(ctx *cli.Context) *rules.Engine {
+	file := ctx.GlobalString(utils.RulesFlag.Name)
+	if file == "" {
+		return nil
+	}
+	path := ctx.GlobalString(utils.AuditLogFlag.Name)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(utils.MakeDataDir(ctx), path)
+	}
+	audit, err := rules.NewAuditLog(path)
+	if err != nil {
+		utils.Fatalf("Failed to open audit log: %v", err)
+	}
+	// requests left to the user are rejected when there is none to prompt,
+	// the prompt would otherwise consume the piped input
+	var confirm rules.ConfirmFunc
+	if console.StdinIsTerminal() {
+		confirm = console.Stdin.PromptConfirm
+	}
+	engine, err := rules.NewEngine(file, audit, confirm)
+	if err != nil {
+		audit.Close()
+		utils.Fatalf("Failed to load signing rules: %v", err)
+	}
+	return engine
+}
+
 // dialRPC returns a RPC client which connects to the given endpoint.
 // The check for empty endpoint implements the defaulting logic
 // for "geth attach" and "geth monitor" with no argument.
-func dialRPC(endpoint string) (*rpc.Client, error) {
+func dialRPC(endpoint string, opts rpc.Options) (*rpc.Client, error) {
 	if endpoint == "" {
 		//endpoint = node.DefaultIPCEndpoint(clientIdentifier)
 		return nil, errors.New("endpoint is nil")
@@ -131,5 +225,5 @@ func dialRPC(endpoint string) (*rpc.Client, error) {
 		// these prefixes.
 		endpoint = endpoint[4:]
 	}
-	return rpc.Dial(endpoint)
+	return rpc.DialWithOptions(context.Background(), endpoint, opts)
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/DSiSc/astraia/utils"
+	"github.com/DSiSc/astraia/web3ext"
+	"github.com/urfave/cli"
+)
+
+var (
+	helpFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format: text or markdown",
+		Value: web3ext.FormatText,
+	}
+
+	helpMethodsCommand = cli.Command{
+		Action:    utils.MigrateFlags(helpMethods),
+		Name:      "help-methods",
+		Usage:     "Describe the methods of the console",
+		ArgsUsage: "[namespace...]",
+		Flags:     []cli.Flag{helpFormatFlag},
+		Category:  "MISCELLANEOUS COMMANDS",
+		Description: `
+    astraia help-methods --format markdown personal crosschain
+
+Prints the parameters, return value and example of the console methods, the
+ones of the given namespaces only if any are given. The same descriptions are
+shown by help(method) in the console.`,
+	}
+)
+
+func helpMethods(ctx *cli.Context) error {
+	methods := web3ext.Methods
+	if ctx.NArg() > 0 {
+		methods = nil
+		for _, m := range web3ext.Methods {
+			for _, ns := range ctx.Args() {
+				if strings.HasPrefix(m.Name, ns+".") {
+					methods = append(methods, m)
+					break
+				}
+			}
+		}
+		if len(methods) == 0 {
+			utils.Fatalf("No methods in namespaces %s", strings.Join(ctx.Args(), ", "))
+		}
+	}
+	if err := web3ext.WriteHelp(os.Stdout, methods, ctx.String(helpFormatFlag.Name)); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	return nil
+}
@@ -27,6 +27,20 @@ var (
 		utils.LightKDFFlag,
 	}
 
+	// flags that select the config file
+	configFlags = []cli.Flag{
+		local.ConfigFileFlag,
+		local.ProfileFlag,
+		local.NetworkFlag,
+	}
+
+	// flags that configure the logger
+	logFlags = []cli.Flag{
+		local.VerbosityFlag,
+		local.LogFormatFlag,
+		local.LogFileFlag,
+	}
+
 	rpcFlags = []cli.Flag{ }
 	whisperFlags = []cli.Flag{ }
 	metricsFlags = []cli.Flag{ }
@@ -40,14 +54,21 @@ func init() {
 
 	app.Commands = []cli.Command{
 		consoleCommand,
+		runCommand,
+		walletCommand,
+		txCommand,
+		configCommand,
+		helpMethodsCommand,
 	}
 	app.Commands = append(app.Commands, cmd.AccountCommand)
 	sort.Sort(cli.CommandsByName(app.Commands))
 
 	app.Flags = append(app.Flags, nodeFlags...)
+	app.Flags = append(app.Flags, configFlags...)
+	app.Flags = append(app.Flags, logFlags...)
 
 	app.Before = func(ctx *cli.Context) error {
-		return nil
+		return local.SetupLogging(ctx)
 	}
 
 	app.After = func(ctx *cli.Context) error {
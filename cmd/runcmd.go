@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+
+	"github.com/DSiSc/astraia/console"
+	"github.com/DSiSc/astraia/jsre"
+	"github.com/DSiSc/astraia/utils"
+	"github.com/urfave/cli"
+)
+
+var runCommand = cli.Command{
+	Action:    utils.MigrateFlags(runScript),
+	Name:      "run",
+	Usage:     "Run a JavaScript file non-interactively",
+	ArgsUsage: "<file> [args...]",
+	Flags:     append(append(nodeFlags, configFlags...), utils.JSpathFlag, utils.PreloadJSFlag, utils.RulesFlag, utils.AuditLogFlag, utils.OutputFlag, utils.NoColorFlag),
+	Category:  "CONSOLE COMMANDS",
+	Description: `
+    astraia run deploy.js 0x1b192c4e353dc40871066023bf37fc632f1695d4
+
+Runs the script with the console APIs available and exits once its timers
+have fired. The arguments following the file are passed in process.argv after
+"astraia" and the file name. The exit status is the one given to process.exit,
+1 if the script threw an error and 0 otherwise. With --output json the values
+logged with console.log are JSON encoded and errors are written to standard
+error as JSON objects.`,
+}
+
+func runScript(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("This command requires the script file as argument.")
+	}
+	conf := utils.MakeConfig(ctx)
+	client := makeConsoleClient(ctx, conf)
+	engine := makeRulesEngine(ctx)
+	if engine != nil {
+		client.SetRules(engine)
+	}
+	console, err := console.New(console.Config{
+		DataDir: utils.MakeDataDir(ctx),
+		Profile: conf.Profile,
+		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
+		Client:  client,
+		Preload: utils.MakeConsolePreloads(ctx),
+		Output:  utils.MakeConsoleOutput(ctx),
+	})
+	if err != nil {
+		utils.Fatalf("Failed to start the JavaScript console: %v", err)
+	}
+	err = console.Run(ctx.Args().First(), ctx.Args().Tail())
+	console.Stop(false)
+	if engine != nil {
+		engine.Stop()
+	}
+
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case *jsre.ExitError:
+		if err.Code != 0 {
+			os.Exit(err.Code)
+		}
+		return nil
+	default:
+		console.PrintError(err)
+		os.Exit(1)
+	}
+	return nil
+}
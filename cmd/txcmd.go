@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/DSiSc/astraia/api"
+	"github.com/DSiSc/astraia/client"
+	"github.com/DSiSc/astraia/rules"
+	"github.com/DSiSc/astraia/txfile"
+	"github.com/DSiSc/astraia/utils"
+	ctypes "github.com/DSiSc/craft/types"
+	"github.com/DSiSc/p2p/common"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	web3cmn "github.com/DSiSc/web3go/common"
+	"github.com/urfave/cli"
+)
+
+var (
+	txFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Sender address",
+	}
+	txToFlag = cli.StringFlag{
+		Name:  "to",
+		Usage: "Recipient address, empty for contract creation",
+	}
+	txValueFlag = cli.StringFlag{
+		Name:  "value",
+		Usage: "Amount to transfer, decimal or 0x prefixed hex",
+		Value: "0",
+	}
+	txInputFlag = cli.StringFlag{
+		Name:  "input",
+		Usage: "Hex encoded transaction payload",
+		Value: "0x",
+	}
+	txNonceFlag = cli.StringFlag{
+		Name:  "nonce",
+		Usage: "Nonce, resolved from the gateway if not given",
+	}
+	txGasFlag = cli.StringFlag{
+		Name:  "gas",
+		Usage: "Gas limit, estimated by the gateway if not given",
+	}
+	txGasPriceFlag = cli.StringFlag{
+		Name:  "gasprice",
+		Usage: "Gas price, resolved from the gateway if not given",
+	}
+	txOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Transaction file to write",
+		Value: "tx.json",
+	}
+	txSignOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Transaction file to write, the signed file by default",
+	}
+
+	txCommand = cli.Command{
+		Name:     "tx",
+		Usage:    "Build, sign and broadcast transactions offline",
+		Category: "TRANSACTION COMMANDS",
+		Description: `
+Transactions are built on a connected machine, signed on an air-gapped one
+and broadcast from a connected one again. The three steps exchange a
+transaction file, see the README for its format.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "build",
+				Usage:  "Write an unsigned transaction file",
+				Action: utils.MigrateFlags(txBuild),
+				Flags: []cli.Flag{
+					txFromFlag,
+					txToFlag,
+					txValueFlag,
+					txInputFlag,
+					txNonceFlag,
+					txGasFlag,
+					txGasPriceFlag,
+					txOutFlag,
+					utils.ConfigFileFlag,
+					utils.ProfileFlag,
+					utils.NetworkFlag,
+				},
+				Description: `
+    astraia tx build --from <address> --to <address> --value <amount>
+
+Writes the unsigned transaction to --out. The nonce, gas limit and gas price
+are resolved from the api gateway unless given.`,
+			},
+			{
+				Name:      "sign",
+				Usage:     "Sign a transaction file with the keystore, without network access",
+				Action:    utils.MigrateFlags(txSign),
+				Flags:     append(walletFlags, txSignOutFlag, utils.RulesFlag, utils.AuditLogFlag),
+				ArgsUsage: "<file>",
+				Description: `
+    astraia tx sign [--out <file>] [--rules <file>] <file>
+
+Signs the transaction with the key of its sender and writes the file with the
+signature added to --out. With --rules the transaction is passed to the signing
+rules first, as method tx_sign.`,
+			},
+			{
+				Name:      "broadcast",
+				Usage:     "Send a signed transaction file to the gateway",
+				Action:    utils.MigrateFlags(txBroadcast),
+				Flags:     []cli.Flag{utils.ConfigFileFlag, utils.ProfileFlag, utils.NetworkFlag},
+				ArgsUsage: "<file>",
+			},
+		},
+	}
+)
+
+func txBuild(ctx *cli.Context) error {
+	from := ctx.String(txFromFlag.Name)
+	if from == "" {
+		utils.Fatalf("The sender must be given with --from")
+	}
+	if !common.IsHexAddress(from) {
+		utils.Fatalf("Invalid sender address %q", from)
+	}
+	if to := ctx.String(txToFlag.Name); to != "" && !common.IsHexAddress(to) {
+		utils.Fatalf("Invalid recipient address %q", to)
+	}
+	conf := utils.MakeConfig(ctx)
+	web, err := api.NewWeb3(&conf.ApiGateway)
+	if err != nil {
+		utils.Fatalf("Failed to connect to the api gateway: %v", err)
+	}
+
+	nonce := ctx.String(txNonceFlag.Name)
+	if nonce == "" {
+		if nonce, err = api.GetTransactionCount(web, from, "pending"); err != nil {
+			utils.Fatalf("Failed to resolve nonce: %v", err)
+		}
+	}
+	gasPrice := ctx.String(txGasPriceFlag.Name)
+	if gasPrice == "" {
+		if gasPrice, err = api.GetGasPrice(web); err != nil {
+			utils.Fatalf("Failed to resolve gas price: %v", err)
+		}
+	}
+	gas := ctx.String(txGasFlag.Name)
+	if gas == "" {
+		req := &web3cmn.TransactionRequest{
+			From:     from,
+			To:       ctx.String(txToFlag.Name),
+			GasPrice: gasPrice,
+			Value:    fmt.Sprintf("0x%x", parseQuantity(txValueFlag.Name, ctx.String(txValueFlag.Name))),
+			Data:     ctx.String(txInputFlag.Name),
+		}
+		if gas, err = api.EstimateGas(web, req); err != nil {
+			utils.Fatalf("Failed to estimate gas: %v", err)
+		}
+	}
+
+	f := &txfile.File{
+		Version: txfile.Version,
+		Tx: txfile.Tx{
+			From:     from,
+			To:       ctx.String(txToFlag.Name),
+			Nonce:    fmt.Sprintf("0x%x", parseQuantity(txNonceFlag.Name, nonce)),
+			Gas:      fmt.Sprintf("0x%x", parseQuantity(txGasFlag.Name, gas)),
+			GasPrice: fmt.Sprintf("0x%x", parseQuantity(txGasPriceFlag.Name, gasPrice)),
+			Value:    fmt.Sprintf("0x%x", parseQuantity(txValueFlag.Name, ctx.String(txValueFlag.Name))),
+			Input:    ctx.String(txInputFlag.Name),
+		},
+	}
+	if chain := conf.Chain(); chain != nil {
+		f.Network, f.ChainID = chain.Name, chain.ChainID
+	}
+	if _, err := f.Transaction(); err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+	writeTxFile(ctx, f)
+	return nil
+}
+
+func txSign(ctx *cli.Context) error {
+	f := readTxFile(ctx)
+	tx, err := f.Transaction()
+	if err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+	printTx(f)
+
+	conf := utils.MakeConfig(ctx)
+	if chain := conf.Chain(); chain != nil {
+		if err := f.CheckChain(chain.ChainID); err != nil {
+			utils.Fatalf("Refusing to sign: %v", err)
+		}
+	}
+	// the signing rules apply offline as well
+	if engine := makeRulesEngine(ctx); engine != nil {
+		req := rules.NewRequest("tx_sign", tx, nil)
+		req.Network, req.ChainID = f.Network, f.ChainID
+		err := engine.Approve(req)
+		engine.Stop()
+		if err != nil {
+			utils.Fatalf("Refusing to sign: %v", err)
+		}
+	}
+	keydir, scryptN, scryptP := utils.MakeKeystoreConfig(ctx, conf)
+	signer := rpc.NewKeystoreSigner(keystore.NewKeyStore(keydir, scryptN, scryptP))
+
+	// the password sources of the config file spare the prompt
+	passwords := utils.MakePasswords(ctx, conf)
+	var password string
+	if passwords.Has(f.Tx.From) {
+		if password, err = passwords.Password(f.Tx.From); err != nil {
+			utils.Fatalf("Failed to read password: %v", err)
+		}
+	} else {
+		password = walletPassword(ctx, false)
+	}
+	raw, err := signer.SignTx(tx, password, f.ChainID)
+	if err != nil {
+		utils.Fatalf("Failed to sign transaction: %v", err)
+	}
+	if err := f.SetSigned(raw); err != nil {
+		utils.Fatalf("Failed to sign transaction: %v", err)
+	}
+	writeTxFile(ctx, f)
+	return nil
+}
+
+func txBroadcast(ctx *cli.Context) error {
+	// api.SendRawTransaction reads the gateway of the selected profile
+	conf := utils.MakeConfig(ctx)
+	f := readTxFile(ctx)
+	if chain := conf.Chain(); chain != nil {
+		if err := f.CheckChain(chain.ChainID); err != nil {
+			utils.Fatalf("Refusing to broadcast: %v", err)
+		}
+	}
+	// the signed transaction is checked against the file and shown as decoded,
+	// not as the fields reviewed at signing
+	tx, err := f.SignedTransaction()
+	if err != nil {
+		utils.Fatalf("Failed to broadcast transaction: %v", err)
+	}
+	signedHash, err := f.SignedHash()
+	if err != nil {
+		utils.Fatalf("Failed to broadcast transaction: %v", err)
+	}
+	printSignedTx(f, tx, signedHash)
+	hash, err := api.SendRawTransaction(tx)
+	if err != nil {
+		utils.Fatalf("Failed to broadcast transaction: %v", err)
+	}
+	fmt.Printf("Transaction hash: 0x%x\n", hash)
+	if chain := conf.Chain(); chain != nil && chain.Explorer != "" {
+		fmt.Printf("Explorer:         %s\n", chain.TxURL(fmt.Sprintf("0x%x", hash)))
+	}
+	return nil
+}
+
+// readTxFile reads the transaction file given as the only argument.
+func readTxFile(ctx *cli.Context) *txfile.File {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the transaction file as argument.")
+	}
+	f, err := txfile.Read(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read transaction file: %v", err)
+	}
+	return f
+}
+
+// writeTxFile writes f to --out, or back to the file given as argument. That
+// file is the only one which may be overwritten.
+func writeTxFile(ctx *cli.Context, f *txfile.File) {
+	out := ctx.String(txOutFlag.Name)
+	if out == "" {
+		out = ctx.Args().First()
+	}
+	if txfile.Exists(out) && out != ctx.Args().First() {
+		utils.Fatalf("Transaction file %s already exists", out)
+	}
+	if err := f.Write(out); err != nil {
+		utils.Fatalf("Failed to write transaction file: %v", err)
+	}
+	fmt.Printf("Transaction written to %s\n", out)
+}
+
+func printTx(f *txfile.File) {
+	if f.Network != "" {
+		fmt.Printf("Network:   %s (chain %d)\n", f.Network, f.ChainID)
+	}
+	fmt.Printf("From:      %s\n", f.Tx.From)
+	fmt.Printf("To:        %s\n", f.Tx.To)
+	fmt.Printf("Value:     %v\n", parseQuantity("value", f.Tx.Value))
+	fmt.Printf("Nonce:     %v\n", parseQuantity("nonce", f.Tx.Nonce))
+	fmt.Printf("Gas:       %v\n", parseQuantity("gas", f.Tx.Gas))
+	fmt.Printf("Gas price: %v\n", parseQuantity("gasPrice", f.Tx.GasPrice))
+	fmt.Printf("Input:     %s\n", f.Tx.Input)
+}
+
+// printSignedTx prints the decoded signed transaction. Its sender was
+// recovered from the signature by txfile.File.SignedTransaction.
+func printSignedTx(f *txfile.File, tx *ctypes.Transaction, hash string) {
+	to := "(contract creation)"
+	if tx.Data.Recipient != nil {
+		to = fmt.Sprintf("0x%x", *tx.Data.Recipient)
+	}
+	fmt.Printf("From:      %s\n", f.Tx.From)
+	fmt.Printf("To:        %s\n", to)
+	fmt.Printf("Value:     %v\n", tx.Data.Amount)
+	fmt.Printf("Nonce:     %d\n", tx.Data.AccountNonce)
+	fmt.Printf("Hash:      %s\n", hash)
+}
+
+// parseQuantity parses a decimal or 0x prefixed hex quantity.
+func parseQuantity(name, s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 {
+		utils.Fatalf("Invalid %s %q", name, s)
+	}
+	return n
+}
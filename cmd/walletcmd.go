@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DSiSc/astraia/console"
+	"github.com/DSiSc/astraia/hdwallet"
+	"github.com/DSiSc/astraia/utils"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	wutils "github.com/DSiSc/wallet/utils"
+	"github.com/urfave/cli"
+)
+
+var (
+	walletCountFlag = cli.IntFlag{
+		Name:  "count",
+		Usage: "Number of accounts to derive from the mnemonic",
+		Value: 1,
+	}
+	walletPathFlag = cli.StringFlag{
+		Name:  "path",
+		Usage: "Base derivation path, the account index is appended to it",
+		Value: hdwallet.DefaultBaseDerivationPath,
+	}
+
+	walletFlags = []cli.Flag{
+		wutils.DataDirFlag,
+		wutils.KeyStoreDirFlag,
+		wutils.PasswordFileFlag,
+		wutils.LightKDFFlag,
+		utils.ConfigFileFlag,
+		utils.ProfileFlag,
+		utils.NetworkFlag,
+	}
+
+	walletCommand = cli.Command{
+		Name:     "wallet",
+		Usage:    "Manage mnemonic based hierarchical deterministic accounts",
+		Category: "ACCOUNT COMMANDS",
+		Description: `
+Create or restore a BIP-39 mnemonic wallet and derive BIP-32/44 accounts from
+it. The mnemonic is stored encrypted in the data directory, derived accounts
+are imported into the keystore and protected by the same password.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "new",
+				Usage:  "Create a new mnemonic wallet",
+				Action: utils.MigrateFlags(walletNew),
+				Flags:  append(walletFlags, walletCountFlag, walletPathFlag),
+				Description: `
+    astraia wallet new
+
+Generates a new 24 words mnemonic, prints it and derives --count accounts from
+it. Write the mnemonic down, it is the only way to recover the accounts.`,
+			},
+			{
+				Name:   "restore",
+				Usage:  "Restore a mnemonic wallet from an existing mnemonic",
+				Action: utils.MigrateFlags(walletRestore),
+				Flags:  append(walletFlags, walletCountFlag, walletPathFlag),
+				Description: `
+    astraia wallet restore
+
+Prompts for an existing mnemonic and derives --count accounts from it.`,
+			},
+			{
+				Name:      "derive",
+				Usage:     "Derive the account at the given index",
+				Action:    utils.MigrateFlags(walletDerive),
+				Flags:     walletFlags,
+				ArgsUsage: "<index>",
+			},
+			{
+				Name:   "list",
+				Usage:  "Print the accounts derived so far",
+				Action: utils.MigrateFlags(walletList),
+				Flags:  walletFlags,
+			},
+		},
+	}
+)
+
+// makeHDWallet opens the hd wallet store of the keystore used by the console.
+func makeHDWallet(ctx *cli.Context) *hdwallet.Store {
+	keydir, scryptN, scryptP := utils.MakeKeystoreConfig(ctx, utils.MakeConfig(ctx))
+	ks := keystore.NewKeyStore(keydir, scryptN, scryptP)
+	return hdwallet.NewStore(filepath.Dir(keydir), ks, scryptN, scryptP)
+}
+
+// walletPassword reads the wallet password from the --password file or prompts
+// the user for it.
+func walletPassword(ctx *cli.Context, confirmation bool) string {
+	if path := ctx.GlobalString(wutils.PasswordFileFlag.Name); path != "" {
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			utils.Fatalf("Failed to read password file: %v", err)
+		}
+		return strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
+	}
+	password, err := console.Stdin.PromptPassword("Passphrase: ")
+	if err != nil {
+		utils.Fatalf("Failed to read passphrase: %v", err)
+	}
+	if confirmation {
+		confirm, err := console.Stdin.PromptPassword("Repeat passphrase: ")
+		if err != nil {
+			utils.Fatalf("Failed to read passphrase confirmation: %v", err)
+		}
+		if password != confirm {
+			utils.Fatalf("Passphrases do not match")
+		}
+	}
+	return password
+}
+
+func walletNew(ctx *cli.Context) error {
+	mnemonic, err := hdwallet.NewMnemonic(hdwallet.DefaultEntropyBits)
+	if err != nil {
+		utils.Fatalf("Failed to generate mnemonic: %v", err)
+	}
+	store := makeHDWallet(ctx)
+	if store.Exists() {
+		utils.Fatalf("Failed to create wallet: %v", hdwallet.ErrWalletExists)
+	}
+	fmt.Println("Your new wallet is locked with a password. Please give a password. Do not forget this password.")
+	password := walletPassword(ctx, true)
+
+	wallet, err := store.Create(mnemonic, password, ctx.String(walletPathFlag.Name), ctx.Int(walletCountFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to create wallet: %v", err)
+	}
+	fmt.Printf("Mnemonic: %s\n\n", mnemonic)
+	fmt.Println("Write the mnemonic down and keep it safe, it is the only way to recover your accounts.")
+	printWallet(wallet)
+	return nil
+}
+
+func walletRestore(ctx *cli.Context) error {
+	store := makeHDWallet(ctx)
+	if store.Exists() {
+		utils.Fatalf("Failed to restore wallet: %v", hdwallet.ErrWalletExists)
+	}
+	mnemonic, err := console.Stdin.PromptPassword("Mnemonic: ")
+	if err != nil {
+		utils.Fatalf("Failed to read mnemonic: %v", err)
+	}
+	fmt.Println("Your restored wallet is locked with a password. Please give a password. Do not forget this password.")
+	password := walletPassword(ctx, true)
+
+	wallet, err := store.Create(mnemonic, password, ctx.String(walletPathFlag.Name), ctx.Int(walletCountFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to restore wallet: %v", err)
+	}
+	printWallet(wallet)
+	return nil
+}
+
+func walletDerive(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the account index as argument.")
+	}
+	index, err := strconv.ParseUint(ctx.Args().First(), 10, 31)
+	if err != nil {
+		utils.Fatalf("Invalid account index %q: %v", ctx.Args().First(), err)
+	}
+	store := makeHDWallet(ctx)
+	if _, err := store.Open(); err != nil {
+		utils.Fatalf("Failed to open wallet: %v", err)
+	}
+	account, err := store.Derive(walletPassword(ctx, false), uint32(index))
+	if err != nil {
+		utils.Fatalf("Failed to derive account: %v", err)
+	}
+	fmt.Printf("Account #%d: {%s} %s\n", account.Index, account.Address, account.Path)
+	return nil
+}
+
+func walletList(ctx *cli.Context) error {
+	wallet, err := makeHDWallet(ctx).Open()
+	if err != nil {
+		utils.Fatalf("Failed to open wallet: %v", err)
+	}
+	printWallet(wallet)
+	return nil
+}
+
+func printWallet(wallet *hdwallet.Wallet) {
+	for _, account := range wallet.Accounts {
+		fmt.Printf("Account #%d: {%s} %s\n", account.Index, account.Address, account.Path)
+	}
+}
@@ -5,59 +5,430 @@ import (
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
-	"github.com/DSiSc/craft/log"
+	"sync"
+	"time"
+	"github.com/DSiSc/astraia/log"
+	"github.com/DSiSc/astraia/secrets"
 )
 
 const (
 	// config file prefix
 	ConfigPrefix = "light_client"
+	// prefix of the environment variables overriding settings, see EnvName
+	EnvPrefix = "ASTRAIA"
+	// environment variable naming the config file
+	EnvConfigFile = "ASTRAIA_CONFIG"
+	// environment variable naming the profile
+	EnvProfile = "ASTRAIA_PROFILE"
+	// named profiles overriding the top level settings
+	ProfilesKey = "profiles"
+	// network selected from the networks section
+	NetworkKey  = "network"
+	NetworksKey = "networks"
 	// api gateway
 	ApiHostName = "apigateway.hostname"
 	ApiPort = "apigateway.port"
+	ApiURL  = "apigateway.url"
+	ApiTimeout = "apigateway.timeout"
+	// api gateway transport security and authentication
+	ApiTLSCAFile             = "apigateway.tls.cafile"
+	ApiTLSCertFile           = "apigateway.tls.certfile"
+	ApiTLSKeyFile            = "apigateway.tls.keyfile"
+	ApiTLSInsecureSkipVerify = "apigateway.tls.insecureskipverify"
+	ApiAuthUsername          = "apigateway.auth.username"
+	ApiAuthPassword          = "apigateway.auth.password"
+	ApiAuthToken             = "apigateway.auth.token"
+	// keystore
+	KeystoreDataDir  = "keystore.datadir"
+	KeystoreDir      = "keystore.dir"
+	KeystoreLightKDF = "keystore.lightkdf"
+	// logging
+	LogLevel      = "log.level"
+	LogFormat     = "log.format"
+	LogFile       = "log.file"
+	LogMaxSize    = "log.maxsize"
+	LogMaxBackups = "log.maxbackups"
+	// password sources
+	PasswordsDefault = "passwords.default"
+	// console
+	ConsolePrompt = "console.prompt"
 )
 
 
-func LoadConfig() (config *viper.Viper) {
-	config = viper.New()
-	// for environment variables
-	config.SetEnvPrefix(ConfigPrefix)
-	config.AutomaticEnv()
-	replacer := strings.NewReplacer(".", "_")
-	config.SetEnvKeyReplacer(replacer)
+// Config is the typed content of light_client.yaml.
+type Config struct {
+	Network    string             `mapstructure:"network"`  // selected network, see Networks
+	Networks   map[string]Network `mapstructure:"networks"` // networks by name
+	ApiGateway ApiGatewayConfig `mapstructure:"apigateway"`
+	Keystore   KeystoreConfig   `mapstructure:"keystore"`
+	Log        LogConfig        `mapstructure:"log"`
+	Passwords  PasswordsConfig  `mapstructure:"passwords"`
+	Console    ConsoleConfig    `mapstructure:"console"`
 
-	config.SetConfigName("light_client")
+	File    string `mapstructure:"-"` // config file read, empty for the defaults
+	Profile string `mapstructure:"-"` // profile applied, empty for none
+
+	explicitURL bool // apigateway.url was set, the network keeps it
+}
+
+// ApiGatewayConfig locates the api gateway the console talks to. URL takes
+// precedence over HostName and Port, which only describe plain http gateways.
+type ApiGatewayConfig struct {
+	URL      string     `mapstructure:"url"`
+	HostName string     `mapstructure:"hostname"`
+	Port     int           `mapstructure:"port"`
+	Timeout  time.Duration `mapstructure:"timeout"` // of a request, 0 for the default
+	TLS      TLSConfig     `mapstructure:"tls"`
+	Auth     AuthConfig    `mapstructure:"auth"`
+}
+
+// TLSConfig configures https connections to the api gateway.
+type TLSConfig struct {
+	CAFile             string `mapstructure:"cafile"`             // PEM bundle of additional trusted CAs
+	CertFile           string `mapstructure:"certfile"`           // PEM client certificate
+	KeyFile            string `mapstructure:"keyfile"`            // PEM key of the client certificate
+	InsecureSkipVerify bool   `mapstructure:"insecureskipverify"` // for development gateways only
+}
+
+// AuthConfig holds the credentials sent to the api gateway, either basic
+// authentication or a bearer token.
+type AuthConfig struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Token    string `mapstructure:"token"`
+}
+
+// Endpoint returns the URL of the api gateway.
+func (gw *ApiGatewayConfig) Endpoint() string {
+	if gw.URL != "" {
+		return gw.URL
+	}
+	return "http://" + net.JoinHostPort(gw.HostName, strconv.Itoa(gw.Port))
+}
+
+// KeystoreConfig locates the keystore of the console, see utils.MakeKeystoreConfig.
+type KeystoreConfig struct {
+	DataDir  string `mapstructure:"datadir"`
+	Dir      string `mapstructure:"dir"`
+	LightKDF bool   `mapstructure:"lightkdf"`
+}
+
+// LogConfig configures the logger, see log.Options.
+type LogConfig struct {
+	Level      string `mapstructure:"level"`
+	Format     string `mapstructure:"format"`
+	File       string `mapstructure:"file"`
+	MaxSize    int    `mapstructure:"maxsize"`
+	MaxBackups int    `mapstructure:"maxbackups"`
+}
+
+// ConsoleConfig configures the interactive console.
+type ConsoleConfig struct {
+	Prompt string `mapstructure:"prompt"` // prompt template, see console.PromptContext
+}
+
+// Options returns the logger options of the configuration.
+func (c *LogConfig) Options() log.Options {
+	return log.Options{
+		Level:      c.Level,
+		Format:     c.Format,
+		File:       c.File,
+		MaxSize:    c.MaxSize,
+		MaxBackups: c.MaxBackups,
+	}
+}
+
+// PasswordsConfig selects the sources of the account passwords used by the
+// local handlers when a script passes none, see secrets.Parse.
+type PasswordsConfig struct {
+	Default  string            `mapstructure:"default"`  // source of accounts without own source
+	Accounts map[string]string `mapstructure:"accounts"` // sources by address
+}
+
+// Store creates the password store of the configuration.
+func (c *PasswordsConfig) Store() (*secrets.Store, error) {
+	return secrets.NewStore(c.Default, c.Accounts)
+}
+
+// Default returns the configuration used when no config file exists.
+func Default() *Config {
+	return &Config{
+		ApiGateway: ApiGatewayConfig{
+			HostName: "127.0.0.1",
+			Port:     47768,
+			Timeout:  30 * time.Second,
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Format:     log.FormatText,
+			MaxSize:    100,
+			MaxBackups: 3,
+		},
+	}
+}
+
+var hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// Validate checks the fields of the configuration, reporting the first invalid one.
+func (c *Config) Validate() error {
+	if err := c.ApiGateway.Validate(); err != nil {
+		return err
+	}
+	options := c.Log.Options()
+	if err := options.Validate(); err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+	if _, err := c.Passwords.Store(); err != nil {
+		return fmt.Errorf("passwords: %v", err)
+	}
+	return nil
+}
+
+// Validate checks the fields of the gateway configuration, reporting the first
+// invalid one.
+func (gw *ApiGatewayConfig) Validate() error {
+	host := gw.HostName
+	switch {
+	case host == "":
+		return fmt.Errorf("%s: must not be empty", ApiHostName)
+	case strings.Contains(host, "://"):
+		return fmt.Errorf("%s: %q must be a host name without URL scheme", ApiHostName, host)
+	case net.ParseIP(host) == nil && !hostnameRegexp.MatchString(host):
+		return fmt.Errorf("%s: %q is not a valid host name or IP address", ApiHostName, host)
+	}
+	if port := gw.Port; port < 1 || port > 65535 {
+		return fmt.Errorf("%s: %d is out of range 1-65535", ApiPort, port)
+	}
+	if gw.Timeout < 0 {
+		return fmt.Errorf("%s: %v must not be negative", ApiTimeout, gw.Timeout)
+	}
+	if rawurl := gw.URL; rawurl != "" {
+		u, err := url.Parse(rawurl)
+		switch {
+		case err != nil:
+			return fmt.Errorf("%s: %v", ApiURL, err)
+		case u.Scheme != "http" && u.Scheme != "https":
+			return fmt.Errorf("%s: %q has scheme %q, expected http or https", ApiURL, rawurl, u.Scheme)
+		case u.Host == "":
+			return fmt.Errorf("%s: %q has no host", ApiURL, rawurl)
+		}
+	}
+	tls := gw.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		return fmt.Errorf("%s and %s must be given together", ApiTLSCertFile, ApiTLSKeyFile)
+	}
+	auth := gw.Auth
+	if auth.Token != "" && (auth.Username != "" || auth.Password != "") {
+		return fmt.Errorf("%s excludes %s and %s", ApiAuthToken, ApiAuthUsername, ApiAuthPassword)
+	}
+	if auth.Password != "" && auth.Username == "" {
+		return fmt.Errorf("%s requires %s", ApiAuthPassword, ApiAuthUsername)
+	}
+	return nil
+}
+
+// newViper creates the viper instance reading light_client.yaml from paths.
+func newViper(paths []string) *viper.Viper {
+	config := viper.New()
+	config.SetConfigName(ConfigPrefix)
+	for _, path := range paths {
+		config.AddConfigPath(path)
+	}
+	return config
+}
+
+// applyEnv sets the settings overridden by ASTRAIA_* environment variables
+// in config, see EnvName. The LIGHT_CLIENT_* names of earlier releases are
+// still honored.
+func applyEnv(config *viper.Viper) error {
+	for _, key := range Keys {
+		name, value, ok := lookupEnv(key.Name)
+		if !ok {
+			continue
+		}
+		if name != EnvName(key.Name) {
+			log.Warn("Deprecated environment variable, use "+EnvName(key.Name), "name", name)
+		}
+		typed, err := parseValue(key.Name, value)
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %v", name, err)
+		}
+		config.Set(key.Name, typed)
+	}
+	return nil
+}
+
+// searchPaths returns the directories searched for light_client.yaml.
+func searchPaths() []string {
 	homePath, _ := Home()
-	config.AddConfigPath(fmt.Sprintf("%s/.astraia", homePath))
+	paths := []string{fmt.Sprintf("%s/.astraia", homePath)}
 	// Path to look for the config file in based on GOPATH
 	goPath := os.Getenv("GOPATH")
 	for _, p := range filepath.SplitList(goPath) {
-		config.AddConfigPath(filepath.Join(p, "src/github.com/DSiSc/astraia/config"))
+		paths = append(paths, filepath.Join(p, "src/github.com/DSiSc/astraia/config"))
 	}
+	return paths
+}
 
-	err := config.ReadInConfig()
+// Load reads and validates the config file, applying the settings of the
+// named profile on top of the top level ones if profile is not empty. The file
+// defaults to $ASTRAIA_CONFIG, then to light_client.yaml in the search paths;
+// the defaults are returned if no config file exists. The profile defaults to
+// $ASTRAIA_PROFILE.
+func Load(file, profile string) (*Config, error) {
+	if file == "" {
+		file = os.Getenv(EnvConfigFile)
+	}
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	return load(file, searchPaths(), profile)
+}
+
+func load(file string, paths []string, profile string) (*Config, error) {
+	v, err := read(file, paths)
 	if err != nil {
-		panic(fmt.Errorf("error reading plugin config: %s", err))
+		return nil, err
+	}
+	if err := applyProfile(v, profile); err != nil {
+		return nil, err
 	}
-	return
+	return decode(v, profile)
 }
 
+// read reads file, or light_client.yaml from paths if file is empty.
+func read(file string, paths []string) (*viper.Viper, error) {
+	v := newViper(paths)
+	if file != "" {
+		v.SetConfigFile(file)
+	}
+	if err := v.ReadInConfig(); err != nil {
+		// only a config file which was asked for has to exist
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok || file != "" {
+			return nil, fmt.Errorf("error reading config: %v", err)
+		}
+	}
+	return v, nil
+}
+
+// applyProfile merges the settings of the named profile into v.
+func applyProfile(v *viper.Viper, profile string) error {
+	if profile == "" {
+		return nil
+	}
+	settings := v.GetStringMap(ProfilesKey + "." + profile)
+	if len(settings) == 0 {
+		return fmt.Errorf("unknown profile %q, config %s defines %v", profile, v.ConfigFileUsed(), profileNames(v))
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("error applying profile %q: %v", profile, err)
+	}
+	return nil
+}
+
+// decode applies the environment overrides, then the command line ones, and
+// the defaults to v and decodes the result into a validated configuration.
+func decode(v *viper.Viper, profile string, overrides ...Override) (*Config, error) {
+	if err := applyEnv(v); err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		typed, err := parseValue(o.Key, o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", o.Source, err)
+		}
+		v.Set(o.Key, typed)
+	}
+	conf := Default()
+	if err := v.Unmarshal(conf); err != nil {
+		return nil, fmt.Errorf("error decoding config %s: %v", v.ConfigFileUsed(), err)
+	}
+	conf.File, conf.Profile = v.ConfigFileUsed(), profile
+	conf.explicitURL = v.IsSet(ApiURL)
+	if conf.Network != "" {
+		if err := conf.SetNetwork(conf.Network); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %v", conf.File, err)
+		}
+	}
+	if err := conf.Validate(); err != nil {
+		if profile != "" {
+			return nil, fmt.Errorf("invalid config %s, profile %s: %v", conf.File, profile, err)
+		}
+		return nil, fmt.Errorf("invalid config %s: %v", conf.File, err)
+	}
+	return conf, nil
+}
+
+// profileNames returns the sorted names of the profiles defined in v.
+func profileNames(v *viper.Viper) []string {
+	var names []string
+	for name := range v.GetStringMap(ProfilesKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	lock   sync.Mutex
+	loaded *Config
+)
+
+// Init loads the configuration like Load and makes it the one returned by Get.
+func Init(file, profile string) (*Config, error) {
+	conf, err := Load(file, profile)
+	if err != nil {
+		return nil, err
+	}
+	lock.Lock()
+	loaded = conf
+	lock.Unlock()
+	return conf, nil
+}
+
+// Get returns the configuration set by Init, loading the default config file
+// on first use if Init was not called.
+func Get() (*Config, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if loaded == nil {
+		conf, err := Load("", "")
+		if err != nil {
+			return nil, err
+		}
+		loaded = conf
+	}
+	return loaded, nil
+}
+
+// mustGet returns the configuration for the accessors below, which predate
+// Get and have no way to report an error.
+func mustGet() *Config {
+	conf, err := Get()
+	if err != nil {
+		panic(err)
+	}
+	return conf
+}
 
 func GetApiGatewayHostName() string {
-	conf := LoadConfig()
-	apiGatewayHostName := conf.GetString(ApiHostName)
-	return apiGatewayHostName
+	return mustGet().ApiGateway.HostName
 }
 
 func GetApiGatewayPort() string {
-	conf := LoadConfig()
-	apiGatewayPort := conf.GetString(ApiPort)
-	return apiGatewayPort
+	return strconv.Itoa(mustGet().ApiGateway.Port)
 }
 
 func Home() (string, error) {
@@ -85,13 +456,13 @@ func homeUnix() (string, error) {
 	cmd := exec.Command("sh", "-c", "eval echo ~$USER")
 	cmd.Stdout = &stdout
 	if err := cmd.Run(); err != nil {
-		log.Error("sh -c eval echo ~$USER error.")
+		log.Error("Failed to read home directory", "cmd", "sh -c eval echo ~$USER", "err", err)
 		return "", err
 	}
 
 	result := strings.TrimSpace(stdout.String())
 	if result == "" {
-		log.Error("blank output when reading home directory")
+		log.Error("Blank output when reading home directory")
 		return "", errors.New("blank output when reading home directory")
 	}
 
@@ -106,7 +477,7 @@ func homeWindows() (string, error) {
 		home = os.Getenv("USERPROFILE")
 	}
 	if home == "" {
-		log.Error("Get home path error.")
+		log.Error("Failed to read home directory", "env", "HOMEDRIVE, HOMEPATH, USERPROFILE")
 		return "", errors.New("HOMEDRIVE, HOMEPATH, and USERPROFILE are blank")
 	}
 
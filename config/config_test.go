@@ -2,7 +2,12 @@ package config
 
 import (
 	"github.com/magiconair/properties/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 )
 
 func TestGetApiGatewayHostName(t *testing.T) {
@@ -14,3 +19,200 @@ func TestGetApiGatewayPort(t *testing.T) {
 	port := GetApiGatewayPort()
 	assert.Equal(t, "47768", port)
 }
+
+func TestGetKeystoreConfig(t *testing.T) {
+	conf, err := Get()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, KeystoreConfig{}, conf.Keystore)
+}
+
+func TestLoadDefaults(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	conf, err := load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Default(), conf)
+
+	_, err = load(filepath.Join(dir, "missing.yaml"), nil, "")
+	assert.Equal(t, true, err != nil)
+}
+
+func TestLoad(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	yaml := "apigateway:\n  hostname: gateway.example.com\n  port: 8545\nkeystore:\n  dir: /tmp/keystore\n  lightkdf: true\n"
+	ioutil.WriteFile(filepath.Join(dir, ConfigPrefix+".yaml"), []byte(yaml), 0644)
+	conf, err := load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "gateway.example.com", conf.ApiGateway.HostName)
+	assert.Equal(t, 8545, conf.ApiGateway.Port)
+	assert.Equal(t, KeystoreConfig{Dir: "/tmp/keystore", LightKDF: true}, conf.Keystore)
+
+	ioutil.WriteFile(filepath.Join(dir, ConfigPrefix+".yaml"), []byte("apigateway:\n  port: 70000\n"), 0644)
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), "apigateway.port: 70000 is out of range")
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		host string
+		port int
+		err  string
+	}{
+		{"127.0.0.1", 47768, ""},
+		{"::1", 1, ""},
+		{"gateway-1.example.com", 65535, ""},
+		{"", 47768, "apigateway.hostname: must not be empty"},
+		{"http://127.0.0.1", 47768, "must be a host name without URL scheme"},
+		{"bad host", 47768, "is not a valid host name or IP address"},
+		{"127.0.0.1", 0, "apigateway.port: 0 is out of range 1-65535"},
+		{"127.0.0.1", 65536, "apigateway.port: 65536 is out of range 1-65535"},
+	} {
+		conf := Default()
+		conf.ApiGateway.HostName, conf.ApiGateway.Port = test.host, test.port
+		err := conf.Validate()
+		if test.err == "" {
+			assert.Equal(t, nil, err, test.host)
+		} else {
+			assert.Matches(t, err.Error(), test.err)
+		}
+	}
+}
+
+func TestLoadPasswords(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	yaml := "passwords:\n  default: env:WALLET_PASSWORD\n  accounts:\n    0x5E4BD1E8D43F2A4C3B4F2BD3C8E7B1A2D3F4E5A6: file:/run/secrets/ab\n"
+	ioutil.WriteFile(filepath.Join(dir, ConfigPrefix+".yaml"), []byte(yaml), 0644)
+	conf, err := load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "env:WALLET_PASSWORD", conf.Passwords.Default)
+	assert.Equal(t, map[string]string{"0x5e4bd1e8d43f2a4c3b4f2bd3c8e7b1a2d3f4e5a6": "file:/run/secrets/ab"}, conf.Passwords.Accounts)
+
+	ioutil.WriteFile(filepath.Join(dir, ConfigPrefix+".yaml"), []byte("passwords:\n  default: hunter2\n"), 0644)
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), `passwords: password source "hunter2" has no scheme`)
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	yaml := `apigateway:
+  hostname: 127.0.0.1
+  port: 47768
+profiles:
+  testnet:
+    apigateway:
+      hostname: testnet.example.com
+  mainnet:
+    apigateway:
+      hostname: mainnet.example.com
+      port: 443
+    keystore:
+      dir: /secure/keystore
+`
+	file := filepath.Join(dir, "networks.yaml")
+	ioutil.WriteFile(file, []byte(yaml), 0644)
+
+	conf, err := load(file, nil, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ApiGatewayConfig{HostName: "127.0.0.1", Port: 47768, Timeout: 30 * time.Second}, conf.ApiGateway)
+	assert.Equal(t, file, conf.File)
+
+	conf, err = load(file, nil, "testnet")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ApiGatewayConfig{HostName: "testnet.example.com", Port: 47768, Timeout: 30 * time.Second}, conf.ApiGateway)
+	assert.Equal(t, "testnet", conf.Profile)
+
+	conf, err = load(file, nil, "mainnet")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ApiGatewayConfig{HostName: "mainnet.example.com", Port: 443, Timeout: 30 * time.Second}, conf.ApiGateway)
+	assert.Equal(t, "/secure/keystore", conf.Keystore.Dir)
+
+	_, err = load(file, nil, "devnet")
+	assert.Matches(t, err.Error(), `unknown profile "devnet", config .* defines \[mainnet testnet\]`)
+}
+
+func TestLoadEnvConfigFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "env.yaml")
+	ioutil.WriteFile(file, []byte("apigateway:\n  hostname: env.example.com\n  port: 1234\n"), 0644)
+	os.Setenv(EnvConfigFile, file)
+	defer os.Unsetenv(EnvConfigFile)
+
+	conf, err := Load("", "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ApiGatewayConfig{HostName: "env.example.com", Port: 1234, Timeout: 30 * time.Second}, conf.ApiGateway)
+}
+
+func TestLoadEnv(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	for name, value := range map[string]string{
+		"ASTRAIA_APIGATEWAY_PORT":    "8545",
+		"ASTRAIA_APIGATEWAY_TIMEOUT": "1m",
+		"ASTRAIA_KEYSTORE_LIGHTKDF":  "true",
+		"ASTRAIA_LOG_FORMAT":         "json",
+		"LIGHT_CLIENT_KEYSTORE_DIR":  "/legacy/keystore",
+	} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	conf, err := load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 8545, conf.ApiGateway.Port)
+	assert.Equal(t, time.Minute, conf.ApiGateway.Timeout)
+	assert.Equal(t, KeystoreConfig{Dir: "/legacy/keystore", LightKDF: true}, conf.Keystore)
+	assert.Equal(t, "json", conf.Log.Format)
+
+	// the new name takes precedence, values are checked against the type
+	os.Setenv("ASTRAIA_KEYSTORE_DIR", "/env/keystore")
+	defer os.Unsetenv("ASTRAIA_KEYSTORE_DIR")
+	conf, err = load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "/env/keystore", conf.Keystore.Dir)
+
+	os.Setenv("ASTRAIA_APIGATEWAY_PORT", "http")
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), `environment variable ASTRAIA_APIGATEWAY_PORT: apigateway.port: "http" is not a number`)
+}
+
+func TestValidateGateway(t *testing.T) {
+	for _, test := range []struct {
+		gateway ApiGatewayConfig
+		err     string
+	}{
+		{ApiGatewayConfig{URL: "https://gateway.example.com/rpc"}, ""},
+		{ApiGatewayConfig{URL: "ws://gateway.example.com"}, `apigateway.url: "ws://gateway.example.com" has scheme "ws", expected http or https`},
+		{ApiGatewayConfig{URL: "https:///rpc"}, "has no host"},
+		{ApiGatewayConfig{TLS: TLSConfig{CertFile: "client.pem"}}, "apigateway.tls.certfile and apigateway.tls.keyfile must be given together"},
+		{ApiGatewayConfig{Auth: AuthConfig{Username: "a", Token: "t"}}, "apigateway.auth.token excludes"},
+		{ApiGatewayConfig{Auth: AuthConfig{Password: "p"}}, "apigateway.auth.password requires apigateway.auth.username"},
+	} {
+		conf := Default()
+		test.gateway.HostName, test.gateway.Port = conf.ApiGateway.HostName, conf.ApiGateway.Port
+		conf.ApiGateway = test.gateway
+		err := conf.Validate()
+		if test.err == "" {
+			assert.Equal(t, nil, err)
+		} else {
+			assert.Matches(t, err.Error(), regexp.QuoteMeta(test.err))
+		}
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	gw := Default().ApiGateway
+	assert.Equal(t, "http://127.0.0.1:47768", gw.Endpoint())
+	gw.HostName = "::1"
+	assert.Equal(t, "http://[::1]:47768", gw.Endpoint())
+	gw.URL = "https://gateway.example.com/rpc"
+	assert.Equal(t, "https://gateway.example.com/rpc", gw.Endpoint())
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Network describes a DSiSc network of the networks section of the config
+// file, selected with --network or the network key. No networks are built in:
+// DSiSc publishes no chain IDs or public endpoints, so they are configured.
+type Network struct {
+	Name         string `mapstructure:"-"`            // key in the networks section
+	ChainID      uint64 `mapstructure:"chainid"`      // signed into transactions
+	Gateway      string `mapstructure:"gateway"`      // default api gateway URL
+	Explorer     string `mapstructure:"explorer"`     // block explorer URL, empty if there is none
+	Denomination string `mapstructure:"denomination"` // symbol of the native token, empty if unknown
+}
+
+// Validate checks the fields of the network, reporting the first invalid one.
+func (n *Network) Validate() error {
+	prefix := NetworksKey + "." + n.Name
+	if n.ChainID == 0 {
+		return fmt.Errorf("%s.chainid: must be set", prefix)
+	}
+	for _, field := range []struct{ key, rawurl string }{{"gateway", n.Gateway}, {"explorer", n.Explorer}} {
+		key, rawurl := field.key, field.rawurl
+		if rawurl == "" && key == "explorer" {
+			continue
+		}
+		u, err := url.Parse(rawurl)
+		switch {
+		case err != nil:
+			return fmt.Errorf("%s.%s: %v", prefix, key, err)
+		case u.Scheme != "http" && u.Scheme != "https":
+			return fmt.Errorf("%s.%s: %q has scheme %q, expected http or https", prefix, key, rawurl, u.Scheme)
+		case u.Host == "":
+			return fmt.Errorf("%s.%s: %q has no host", prefix, key, rawurl)
+		}
+	}
+	return nil
+}
+
+// TxURL returns the explorer page of a transaction, empty if the network has
+// no explorer.
+func (n *Network) TxURL(hash string) string {
+	if n.Explorer == "" {
+		return ""
+	}
+	return strings.TrimRight(n.Explorer, "/") + "/tx/" + hash
+}
+
+// String returns the summary shown in the console welcome banner.
+func (n *Network) String() string {
+	if n.Denomination == "" {
+		return fmt.Sprintf("%s (chain %d)", n.Name, n.ChainID)
+	}
+	return fmt.Sprintf("%s (chain %d, %s)", n.Name, n.ChainID, n.Denomination)
+}
+
+// LookupNetwork returns the named network of the networks section.
+func (c *Config) LookupNetwork(name string) (*Network, bool) {
+	for key, n := range c.Networks {
+		if strings.EqualFold(key, name) {
+			n.Name = key
+			return &n, true
+		}
+	}
+	return nil, false
+}
+
+// NetworkNames returns the sorted names of the networks of the networks section.
+func (c *Config) NetworkNames() []string {
+	names := make([]string, 0, len(c.Networks))
+	for name := range c.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetNetwork selects the named network. Its gateway replaces the configured
+// one unless apigateway.url was set explicitly.
+func (c *Config) SetNetwork(name string) error {
+	n, ok := c.LookupNetwork(name)
+	if !ok {
+		if len(c.Networks) == 0 {
+			return fmt.Errorf("%s: unknown network %q, the %s section defines none", NetworkKey, name, NetworksKey)
+		}
+		return fmt.Errorf("%s: unknown network %q, expected one of %s", NetworkKey, name, strings.Join(c.NetworkNames(), ", "))
+	}
+	if err := n.Validate(); err != nil {
+		return err
+	}
+	c.Network = n.Name
+	if !c.explicitURL {
+		c.ApiGateway.URL = n.Gateway
+	}
+	return nil
+}
+
+// Chain returns the selected network, nil if none is selected.
+func (c *Config) Chain() *Network {
+	n, _ := c.LookupNetwork(c.Network)
+	return n
+}
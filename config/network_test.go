@@ -0,0 +1,70 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestLoadNetwork(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, ConfigPrefix+".yaml")
+
+	const networks = `networks:
+  testnet:
+    chainid: 4242
+    gateway: https://gateway.testnet.example.com/rpc
+    explorer: https://explorer.example.com
+    denomination: DSC
+  devnet:
+    chainid: 1337
+    gateway: http://127.0.0.1:47768
+`
+	ioutil.WriteFile(file, []byte(networks+"network: testnet\n"), 0644)
+	conf, err := load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	testnet := &Network{Name: "testnet", ChainID: 4242, Gateway: "https://gateway.testnet.example.com/rpc", Explorer: "https://explorer.example.com", Denomination: "DSC"}
+	assert.Equal(t, testnet, conf.Chain())
+	assert.Equal(t, testnet.Gateway, conf.ApiGateway.Endpoint())
+	assert.Equal(t, "testnet (chain 4242, DSC)", conf.Chain().String())
+	assert.Equal(t, []string{"devnet", "testnet"}, conf.NetworkNames())
+
+	// an explicit gateway URL takes precedence over the network, also when the
+	// network is selected later by --network
+	ioutil.WriteFile(file, []byte(networks+"network: testnet\napigateway:\n  url: https://gateway.example.com/rpc\n"), 0644)
+	conf, err = load("", []string{dir}, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "https://gateway.example.com/rpc", conf.ApiGateway.Endpoint())
+	assert.Equal(t, nil, conf.SetNetwork("DevNet"))
+	assert.Equal(t, uint64(1337), conf.Chain().ChainID)
+	assert.Equal(t, "devnet (chain 1337)", conf.Chain().String())
+	assert.Equal(t, "https://gateway.example.com/rpc", conf.ApiGateway.Endpoint())
+
+	// networks are not built in
+	ioutil.WriteFile(file, []byte("network: mainnet\n"), 0644)
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), `network: unknown network "mainnet", the networks section defines none`)
+	ioutil.WriteFile(file, []byte(networks+"network: mainnet\n"), 0644)
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), `network: unknown network "mainnet", expected one of devnet, testnet`)
+
+	// a network needs its chain ID and gateway
+	ioutil.WriteFile(file, []byte("networks:\n  testnet:\n    gateway: https://gateway.example.com/rpc\nnetwork: testnet\n"), 0644)
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), `networks.testnet.chainid: must be set`)
+	ioutil.WriteFile(file, []byte("networks:\n  testnet:\n    chainid: 4242\nnetwork: testnet\n"), 0644)
+	_, err = load("", []string{dir}, "")
+	assert.Matches(t, err.Error(), `networks.testnet.gateway: "" has scheme "", expected http or https`)
+}
+
+func TestNetworkTxURL(t *testing.T) {
+	n := Network{Name: "testnet", Explorer: "https://explorer.example.com/"}
+	assert.Equal(t, "https://explorer.example.com/tx/0x01", n.TxURL("0x01"))
+	n.Explorer = ""
+	assert.Equal(t, "", n.TxURL("0x01"))
+	assert.Equal(t, (*Network)(nil), Default().Chain())
+}
@@ -0,0 +1,471 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key describes a setting of the config file.
+type Key struct {
+	Name   string // dotted path in the config file
+	Usage  string
+	Secret bool // hidden by config show
+}
+
+// Keys lists every setting of the config file, in display order.
+var Keys = []Key{
+	{Name: NetworkKey, Usage: "Network selected from the networks section"},
+	{Name: ApiURL, Usage: "Full api gateway URL, takes precedence over hostname and port"},
+	{Name: ApiHostName, Usage: "Api gateway host name or IP address"},
+	{Name: ApiPort, Usage: "Api gateway port"},
+	{Name: ApiTimeout, Usage: "Timeout of a gateway request, e.g. 30s"},
+	{Name: ApiTLSCAFile, Usage: "PEM bundle of additional CAs trusted for the gateway"},
+	{Name: ApiTLSCertFile, Usage: "PEM client certificate presented to the gateway"},
+	{Name: ApiTLSKeyFile, Usage: "PEM key of the client certificate"},
+	{Name: ApiTLSInsecureSkipVerify, Usage: "Skip verification of the gateway certificate, development only"},
+	{Name: ApiAuthUsername, Usage: "Basic authentication user"},
+	{Name: ApiAuthPassword, Usage: "Basic authentication password", Secret: true},
+	{Name: ApiAuthToken, Usage: "Bearer authentication token", Secret: true},
+	{Name: KeystoreDataDir, Usage: "Data directory holding the keystore"},
+	{Name: KeystoreDir, Usage: "Keystore directory, relative to the data directory"},
+	{Name: KeystoreLightKDF, Usage: "Encrypt keys with the light scrypt parameters"},
+	{Name: LogLevel, Usage: "Log level: crit, error, warn, info, debug, trace or 0-5"},
+	{Name: LogFormat, Usage: "Log format: text or json"},
+	{Name: LogFile, Usage: "Log file, standard error if empty"},
+	{Name: LogMaxSize, Usage: "Size in megabytes at which the log file is rotated, 0 disables rotation"},
+	{Name: LogMaxBackups, Usage: "Rotated log files kept"},
+	{Name: PasswordsDefault, Usage: "Password source of accounts without own source: file:, env:, keyring: or cmd:"},
+	{Name: ConsolePrompt, Usage: "Console prompt template, e.g. {{.Network}} #{{.Block}}> "},
+}
+
+// values maps the keys of the config file to their value in c.
+func values(c *Config) map[string]interface{} {
+	return map[string]interface{}{
+		NetworkKey:               c.Network,
+		ApiURL:                   c.ApiGateway.URL,
+		ApiHostName:              c.ApiGateway.HostName,
+		ApiPort:                  c.ApiGateway.Port,
+		ApiTimeout:               c.ApiGateway.Timeout,
+		ApiTLSCAFile:             c.ApiGateway.TLS.CAFile,
+		ApiTLSCertFile:           c.ApiGateway.TLS.CertFile,
+		ApiTLSKeyFile:            c.ApiGateway.TLS.KeyFile,
+		ApiTLSInsecureSkipVerify: c.ApiGateway.TLS.InsecureSkipVerify,
+		ApiAuthUsername:          c.ApiGateway.Auth.Username,
+		ApiAuthPassword:          c.ApiGateway.Auth.Password,
+		ApiAuthToken:             c.ApiGateway.Auth.Token,
+		KeystoreDataDir:          c.Keystore.DataDir,
+		KeystoreDir:              c.Keystore.Dir,
+		KeystoreLightKDF:         c.Keystore.LightKDF,
+		LogLevel:                 c.Log.Level,
+		LogFormat:                c.Log.Format,
+		LogFile:                  c.Log.File,
+		LogMaxSize:               c.Log.MaxSize,
+		LogMaxBackups:            c.Log.MaxBackups,
+		PasswordsDefault:         c.Passwords.Default,
+		ConsolePrompt:            c.Console.Prompt,
+	}
+}
+
+// lookupKey returns the description of the named setting.
+func lookupKey(name string) (Key, bool) {
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// EnvName returns the environment variable overriding the named setting,
+// e.g. ASTRAIA_APIGATEWAY_TLS_CAFILE for apigateway.tls.cafile.
+func EnvName(key string) string {
+	return envName(EnvPrefix, key)
+}
+
+func envName(prefix, key string) string {
+	return strings.ToUpper(prefix + "_" + strings.Replace(key, ".", "_", -1))
+}
+
+// lookupEnv returns the environment variable overriding the named setting and
+// its value, falling back to the LIGHT_CLIENT_* name of earlier releases.
+func lookupEnv(key string) (name, value string, ok bool) {
+	for _, name := range []string{EnvName(key), envName(ConfigPrefix, key)} {
+		if value, ok := os.LookupEnv(name); ok {
+			return name, value, true
+		}
+	}
+	return "", "", false
+}
+
+// EnvVar describes the environment variable overriding a setting.
+type EnvVar struct {
+	Name    string
+	Key     string
+	Type    string // string, int, bool or duration
+	Default string
+	Usage   string
+}
+
+// EnvVars lists the environment variables overriding the settings, in the
+// order of Keys, followed by the ones selecting the config file and profile.
+func EnvVars() []EnvVar {
+	defaults := values(Default())
+	vars := make([]EnvVar, 0, len(Keys)+2)
+	for _, key := range Keys {
+		vars = append(vars, EnvVar{
+			Name:    EnvName(key.Name),
+			Key:     key.Name,
+			Type:    typeName(defaults[key.Name]),
+			Default: fmt.Sprint(defaults[key.Name]),
+			Usage:   key.Usage,
+		})
+	}
+	return append(vars,
+		EnvVar{Name: EnvConfigFile, Type: "string", Usage: "Config file, like --config"},
+		EnvVar{Name: EnvProfile, Type: "string", Usage: "Profile applied, like --profile"},
+	)
+}
+
+// typeName returns the type of a setting as shown by EnvVars.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case int:
+		return "int"
+	case bool:
+		return "bool"
+	case time.Duration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// Sources of a setting reported by Describe.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceProfile = "profile"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+	SourceNetwork = "network"
+)
+
+// Setting is the effective value of a key and where it comes from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Override is a setting given on the command line, which takes precedence
+// over the environment, the config file and its profiles.
+type Override struct {
+	Key    string
+	Value  string
+	Source string // reported by Describe, e.g. flag --network
+}
+
+// Describe loads the configuration like Load, applies the overrides and
+// reports the effective value of every key together with its source. Secret
+// values are masked.
+func Describe(file, profile string, overrides ...Override) ([]Setting, *Config, error) {
+	if file == "" {
+		file = os.Getenv(EnvConfigFile)
+	}
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	v, err := read(file, searchPaths())
+	if err != nil {
+		return nil, nil, err
+	}
+	sources := make(map[string]string)
+	for _, key := range Keys {
+		switch {
+		case profile != "" && v.IsSet(ProfilesKey+"."+profile+"."+key.Name):
+			sources[key.Name] = SourceProfile + " " + profile
+		case v.IsSet(key.Name):
+			sources[key.Name] = SourceFile
+		default:
+			sources[key.Name] = SourceDefault
+		}
+		if name, _, ok := lookupEnv(key.Name); ok {
+			sources[key.Name] = SourceEnv + " " + name
+		}
+	}
+	for _, o := range overrides {
+		sources[o.Key] = o.Source
+	}
+	if err := applyProfile(v, profile); err != nil {
+		return nil, nil, err
+	}
+	conf, err := decode(v, profile, overrides...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conf.Network != "" && !conf.explicitURL {
+		sources[ApiURL] = SourceNetwork + " " + conf.Network
+	}
+
+	vals := values(conf)
+	settings := make([]Setting, 0, len(Keys))
+	for _, key := range Keys {
+		value := fmt.Sprint(vals[key.Name])
+		if key.Secret && value != "" {
+			value = "******"
+		}
+		settings = append(settings, Setting{Key: key.Name, Value: value, Source: sources[key.Name]})
+	}
+	return settings, conf, nil
+}
+
+// Profiles returns the names of the profiles defined in the config file.
+func Profiles(file string) ([]string, error) {
+	if file == "" {
+		file = os.Getenv(EnvConfigFile)
+	}
+	v, err := read(file, searchPaths())
+	if err != nil {
+		return nil, err
+	}
+	return profileNames(v), nil
+}
+
+// HomeFile returns the path of the config file in the user's home directory,
+// where config init and config set create it.
+func HomeFile() (string, error) {
+	home, err := Home()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".astraia", ConfigPrefix+".yaml"), nil
+}
+
+// WriteDefault writes the commented default config file to path, refusing to
+// overwrite an existing file.
+func WriteDefault(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(DefaultFile); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Set stores value for key, under the named profile if profile is not empty,
+// in the config file in use, or in HomeFile if there is none. The file is
+// left unchanged if the result does not validate. It returns the path of the
+// file written.
+func Set(file, profile, key, value string) (string, error) {
+	if _, ok := lookupKey(key); !ok {
+		return "", fmt.Errorf("unknown key %q", key)
+	}
+	typed, err := parseValue(key, value)
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		file = os.Getenv(EnvConfigFile)
+	}
+	if file == "" {
+		v, err := read("", searchPaths())
+		if err != nil {
+			return "", err
+		}
+		if file = v.ConfigFileUsed(); file == "" {
+			if file, err = HomeFile(); err != nil {
+				return "", err
+			}
+			if err := WriteDefault(file); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	old, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	// edit the document tree rather than the decoded values, which would
+	// drop the comments of the file
+	var doc yaml.Node
+	if err := yaml.Unmarshal(old, &doc); err != nil {
+		return "", fmt.Errorf("error reading config %s: %v", file, err)
+	}
+	path := strings.Split(key, ".")
+	if profile != "" {
+		path = append([]string{ProfilesKey, profile}, path...)
+	}
+	if err := setNode(&doc, path, typed); err != nil {
+		return "", fmt.Errorf("error editing config %s: %v", file, err)
+	}
+	var blob bytes.Buffer
+	enc := yaml.NewEncoder(&blob)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	enc.Close()
+	if err := ioutil.WriteFile(file, blob.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	if _, err := load(file, nil, profile); err != nil {
+		ioutil.WriteFile(file, old, 0600)
+		return "", err
+	}
+	return file, nil
+}
+
+// parseValue converts value to the type of the key's default.
+func parseValue(key, value string) (interface{}, error) {
+	switch values(Default())[key].(type) {
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", key, value)
+		}
+		return n, nil
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		return b, nil
+	case time.Duration:
+		// kept as string, which is decoded into the duration and stays
+		// readable in the config file
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("%s: %q is not a duration such as 30s", key, value)
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// setNode sets the value at path in doc, creating the intermediate mappings.
+// The comments of the document, including the ones of a replaced value, are
+// kept.
+func setNode(doc *yaml.Node, path []string, value interface{}) error {
+	if doc.Kind == 0 {
+		// empty file
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	node := doc.Content[0]
+	for i, name := range path {
+		switch {
+		case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+			// a section whose settings are all commented out
+			node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+		case node.Kind != yaml.MappingNode:
+			return fmt.Errorf("%s is not a section", strings.Join(path[:i], "."))
+		}
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == name {
+				child = node.Content[j+1]
+				break
+			}
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, child)
+		}
+		node = child
+	}
+	var scalar yaml.Node
+	if err := scalar.Encode(value); err != nil {
+		return err
+	}
+	scalar.HeadComment, scalar.LineComment, scalar.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	*node = scalar
+	return nil
+}
+
+// DefaultFile is the commented config file written by config init.
+const DefaultFile = `# Network selected from the networks section, overridden by --network.
+#network: testnet
+
+# Networks, each with its chain ID, api gateway, block explorer and native
+# token. The gateway is used unless apigateway.url is set.
+#networks:
+#  testnet:
+#    chainid: 4242
+#    gateway: https://gateway.example.com/rpc
+#    explorer: https://explorer.example.com
+#    denomination: DSC
+
+# Api gateway for api.
+apigateway:
+  hostname: 127.0.0.1
+  port: 47768
+  #timeout: 30s
+  # Full gateway URL, takes precedence over hostname and port.
+  #url: https://gateway.example.com/rpc
+  #tls:
+  #  cafile: /etc/astraia/ca.pem
+  #  certfile: /etc/astraia/client.pem
+  #  keyfile: /etc/astraia/client.key
+  #  insecureskipverify: false
+  #auth:
+  #  username: astraia
+  #  password: secret
+  #  token: bearer-token
+
+# Keystore of the console, overridden by --datadir, --keystore and --lightkdf.
+# Without datadir and dir the keystore of the wallet default data directory is used.
+#keystore:
+#  datadir: /home/user/.astraia/dev
+#  dir: keystore
+#  lightkdf: false
+
+# Logging, overridden by --verbosity, --logformat and --logfile.
+#log:
+#  level: info          # crit, error, warn, info, debug, trace or 0-5
+#  format: text         # text or json
+#  file: /home/user/.astraia/astraia.log
+#  maxsize: 100         # megabytes, 0 disables rotation
+#  maxbackups: 3
+
+# Password sources of the accounts, used by personal.unlockAccount and the
+# signing methods when no password is passed: file:<path>, env:<variable>,
+# keyring:<service> or cmd:<command>.
+#passwords:
+#  default: env:ASTRAIA_PASSWORD
+#  accounts:
+#    0x5e4bd1e8d43f2a4c3b4f2bd3c8e7b1a2d3f4e5a6: keyring:astraia
+
+# Prompt of the interactive console, overridden by --prompt. A Go template
+# with the fields .Profile, .Network, .Gateway, .Online, .Status, .Block and
+# .Account, see the README.
+#console:
+#  prompt: "{{.Network}} #{{.Block}} {{short .Account}}> "
+
+# Named profiles, selected with --profile, override the settings above.
+#profiles:
+#  testnet:
+#    apigateway:
+#      hostname: testnet.example.com
+#  mainnet:
+#    apigateway:
+#      hostname: mainnet.example.com
+#    keystore:
+#      datadir: /home/user/.astraia/mainnet
+`
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func settingsByKey(settings []Setting) map[string]Setting {
+	byKey := make(map[string]Setting)
+	for _, setting := range settings {
+		byKey[setting.Key] = setting
+	}
+	return byKey
+}
+
+func TestDescribe(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "describe.yaml")
+	yaml := "apigateway:\n  hostname: gateway.example.com\n  auth:\n    token: secret\nnetworks:\n  testnet:\n    chainid: 4242\n    gateway: https://gateway.example.com/rpc\nprofiles:\n  testnet:\n    apigateway:\n      port: 8545\n"
+	ioutil.WriteFile(file, []byte(yaml), 0644)
+	os.Setenv(EnvName(KeystoreDir), "/env/keystore")
+	defer os.Unsetenv(EnvName(KeystoreDir))
+
+	settings, conf, err := Describe(file, "testnet")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(Keys), len(settings))
+	assert.Equal(t, 8545, conf.ApiGateway.Port)
+
+	byKey := settingsByKey(settings)
+	assert.Equal(t, Setting{ApiHostName, "gateway.example.com", SourceFile}, byKey[ApiHostName])
+	assert.Equal(t, Setting{ApiPort, "8545", "profile testnet"}, byKey[ApiPort])
+	assert.Equal(t, Setting{ApiAuthToken, "******", SourceFile}, byKey[ApiAuthToken])
+	assert.Equal(t, Setting{KeystoreDir, "/env/keystore", "env ASTRAIA_KEYSTORE_DIR"}, byKey[KeystoreDir])
+	assert.Equal(t, Setting{KeystoreLightKDF, "false", SourceDefault}, byKey[KeystoreLightKDF])
+
+	// command line overrides apply like at runtime, the network brings its gateway
+	settings, conf, err = Describe(file, "testnet",
+		Override{NetworkKey, "testnet", "flag --network"},
+		Override{KeystoreLightKDF, "true", "flag --lightkdf"},
+	)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(4242), conf.Chain().ChainID)
+	byKey = settingsByKey(settings)
+	assert.Equal(t, Setting{NetworkKey, "testnet", "flag --network"}, byKey[NetworkKey])
+	assert.Equal(t, Setting{ApiURL, "https://gateway.example.com/rpc", "network testnet"}, byKey[ApiURL])
+	assert.Equal(t, Setting{KeystoreLightKDF, "true", "flag --lightkdf"}, byKey[KeystoreLightKDF])
+
+	_, _, err = Describe(file, "", Override{KeystoreLightKDF, "maybe", "flag --lightkdf"})
+	assert.Matches(t, err.Error(), "flag --lightkdf")
+}
+
+func TestSet(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "set.yaml")
+	assert.Equal(t, nil, WriteDefault(file))
+	assert.Equal(t, true, WriteDefault(file) != nil)
+
+	written, err := Set(file, "", ApiPort, "8545")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, file, written)
+	_, err = Set(file, "mainnet", ApiURL, "https://mainnet.example.com/rpc")
+	assert.Equal(t, nil, err)
+
+	conf, err := load(file, nil, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 8545, conf.ApiGateway.Port)
+	conf, err = load(file, nil, "mainnet")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "https://mainnet.example.com/rpc", conf.ApiGateway.URL)
+
+	// the documentation of the default file is kept
+	blob, _ := ioutil.ReadFile(file)
+	for _, comment := range []string{
+		"# Api gateway for api.\n",
+		"  #timeout: 30s\n",
+		"#  level: info          # crit, error, warn, info, debug, trace or 0-5\n",
+		"# Named profiles, selected with --profile, override the settings above.\n",
+	} {
+		assert.Equal(t, true, strings.Contains(string(blob), comment), comment)
+	}
+
+	// invalid values leave the file unchanged
+	before, _ := ioutil.ReadFile(file)
+	_, err = Set(file, "", ApiPort, "70000")
+	assert.Matches(t, err.Error(), "out of range")
+	_, err = Set(file, "", ApiPort, "port")
+	assert.Matches(t, err.Error(), "is not a number")
+	_, err = Set(file, "", "apigateway.unknown", "x")
+	assert.Matches(t, err.Error(), "unknown key")
+	after, _ := ioutil.ReadFile(file)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestEnvVars(t *testing.T) {
+	vars := EnvVars()
+	assert.Equal(t, len(Keys)+2, len(vars))
+	byKey := make(map[string]EnvVar)
+	for _, v := range vars {
+		byKey[v.Key] = v
+	}
+	assert.Equal(t, EnvVar{"ASTRAIA_APIGATEWAY_PORT", ApiPort, "int", "47768", "Api gateway port"}, byKey[ApiPort])
+	assert.Equal(t, "duration", byKey[ApiTimeout].Type)
+	assert.Equal(t, "30s", byKey[ApiTimeout].Default)
+	assert.Equal(t, "bool", byKey[KeystoreLightKDF].Type)
+	assert.Equal(t, "ASTRAIA_APIGATEWAY_TLS_INSECURESKIPVERIFY", byKey[ApiTLSInsecureSkipVerify].Name)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watch reloads the configuration whenever the file conf was read from
+// changes, applying the same profile. The reloaded configuration becomes the
+// one returned by Get and is passed to onChange; a file which fails to load or
+// validate is reported to onChange and leaves Get unchanged. Nothing is
+// watched if conf was not read from a file.
+func Watch(conf *Config, onChange func(*Config, error)) {
+	if conf.File == "" {
+		return
+	}
+	v := viper.New()
+	v.SetConfigFile(conf.File)
+	v.OnConfigChange(func(fsnotify.Event) {
+		reloaded, err := load(conf.File, nil, conf.Profile)
+		if err == nil {
+			lock.Lock()
+			loaded = reloaded
+			lock.Unlock()
+		}
+		onChange(reloaded, err)
+	})
+	v.WatchConfig()
+}
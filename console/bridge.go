@@ -19,8 +19,8 @@ package console
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/DSiSc/craft/log"
 	"github.com/DSiSc/astraia/client"
+	"github.com/DSiSc/astraia/log"
 	"io"
 	"strings"
 	"time"
@@ -83,6 +83,126 @@ func (b *bridge) NewAccount(call otto.FunctionCall) (response otto.Value) {
 	return ret
 }
 
+// NewMnemonicWallet is a wrapper around the personal.newMnemonicWallet RPC method
+// that uses a non-echoing password prompt to acquire the passphrase protecting
+// the wallet. An optional mnemonic restores an existing wallet instead of
+// generating a new one, an optional count sets how many accounts are derived.
+func (b *bridge) NewMnemonicWallet(call otto.FunctionCall) (response otto.Value) {
+	var (
+		password = call.Argument(0)
+		mnemonic = call.Argument(1)
+		count    = call.Argument(2)
+	)
+	// If password is not given or is the null value, prompt the user for it
+	if password.IsUndefined() || password.IsNull() {
+		input, err := b.prompter.PromptPassword("Passphrase: ")
+		if err != nil {
+			throwJSException(err.Error())
+		}
+		confirm, err := b.prompter.PromptPassword("Repeat passphrase: ")
+		if err != nil {
+			throwJSException(err.Error())
+		}
+		if input != confirm {
+			throwJSException("passphrases don't match!")
+		}
+		password, _ = otto.ToValue(input)
+	}
+	if !password.IsString() {
+		throwJSException("first argument must be the password protecting the wallet")
+	}
+	if mnemonic.IsUndefined() {
+		mnemonic = otto.NullValue()
+	} else if !mnemonic.IsNull() && !mnemonic.IsString() {
+		throwJSException("second argument must be the mnemonic to restore")
+	}
+	if count.IsUndefined() {
+		count = otto.NullValue()
+	} else if !count.IsNull() && !count.IsNumber() {
+		throwJSException("third argument must be the number of accounts to derive")
+	}
+	// Send the request to the backend and return
+	val, err := call.Otto.Call("jeth.newMnemonicWallet", nil, password, mnemonic, count)
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	return val
+}
+
+// DeriveAccount is a wrapper around the personal.deriveAccount RPC method that
+// uses a non-echoing password prompt to acquire the wallet passphrase.
+func (b *bridge) DeriveAccount(call otto.FunctionCall) (response otto.Value) {
+	var (
+		index  = call.Argument(0)
+		passwd = call.Argument(1)
+	)
+	if !index.IsNumber() {
+		throwJSException("first argument must be the index of the account to derive")
+	}
+	// If password is not given or is the null value, prompt the user for it
+	if passwd.IsUndefined() || passwd.IsNull() {
+		if input, err := b.prompter.PromptPassword("Passphrase: "); err != nil {
+			throwJSException(err.Error())
+		} else {
+			passwd, _ = otto.ToValue(input)
+		}
+	}
+	if !passwd.IsString() {
+		throwJSException("second argument must be the password of the wallet")
+	}
+	// Send the request to the backend and return
+	val, err := call.Otto.Call("jeth.deriveAccount", nil, index, passwd)
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	return val
+}
+
+// OpenWallet is a wrapper around personal.openWallet which can interpret and
+// react to certain error messages, such as the PIN matrix or passphrase request
+// of an external signer.
+func (b *bridge) OpenWallet(call otto.FunctionCall) (response otto.Value) {
+	// Make sure we have a wallet specified to open
+	if !call.Argument(0).IsString() {
+		throwJSException("first argument must be the wallet URL to open")
+	}
+	wallet := call.Argument(0)
+
+	var passwd otto.Value
+	if call.Argument(1).IsUndefined() || call.Argument(1).IsNull() {
+		passwd, _ = otto.ToValue("")
+	} else {
+		passwd = call.Argument(1)
+	}
+	// Open the wallet and return if successful in itself
+	val, err := call.Otto.Call("jeth.openWallet", nil, wallet, passwd)
+	if err == nil {
+		return val
+	}
+	// Wallet open failed, report error unless it's a PIN or passphrase entry
+	switch {
+	case strings.HasSuffix(err.Error(), rpc.ErrSignerPINNeeded.Error()):
+		val, err = b.readPinAndReopenWallet(call)
+		if err == nil {
+			return val
+		}
+		// The signer may ask for a passphrase once the PIN was accepted
+		if !strings.HasSuffix(err.Error(), rpc.ErrSignerPassphraseNeeded.Error()) {
+			throwJSException(err.Error())
+		}
+		fallthrough
+	case strings.HasSuffix(err.Error(), rpc.ErrSignerPassphraseNeeded.Error()):
+		val, err = b.readPassphraseAndReopenWallet(call)
+		if err != nil {
+			throwJSException(err.Error())
+		}
+	default:
+		// Unknown error occurred, drop to the user
+		throwJSException(err.Error())
+	}
+	return val
+}
+
 func (b *bridge) readPassphraseAndReopenWallet(call otto.FunctionCall) (otto.Value, error) {
 	var passwd otto.Value
 	wallet := call.Argument(0)
@@ -125,9 +245,12 @@ func (b *bridge) UnlockAccount(call otto.FunctionCall) (response otto.Value) {
 	account := call.Argument(0)
 
 	// If password is not given or is the null value, prompt the user for it
+	// unless a password source of the config file provides it
 	var passwd otto.Value
 
-	if call.Argument(1).IsUndefined() || call.Argument(1).IsNull() {
+	if (call.Argument(1).IsUndefined() || call.Argument(1).IsNull()) && b.client.HasPassword(account.String()) {
+		passwd, _ = otto.ToValue("")
+	} else if call.Argument(1).IsUndefined() || call.Argument(1).IsNull() {
 		fmt.Fprintf(b.printer, "Unlock account %s\n", account)
 		if input, err := b.prompter.PromptPassword("Passphrase: "); err != nil {
 			throwJSException(err.Error())
@@ -338,7 +461,7 @@ func setError(resp *otto.Object, code int, msg string) {
 func throwJSException(msg interface{}) otto.Value {
 	val, err := otto.ToValue(msg)
 	if err != nil {
-		log.Error("Failed to serialize JavaScript exception %s %s, %s %s", "exception", msg, "err", err)
+		log.Error("Failed to serialize JavaScript exception", "exception", msg, "err", err)
 	}
 	panic(val)
 }
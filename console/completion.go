@@ -0,0 +1,202 @@
+package console
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/DSiSc/astraia/web3ext"
+)
+
+// txHashRegexp matches the transaction hashes within the commands of the history.
+var txHashRegexp = regexp.MustCompile(`\b0x[0-9a-fA-F]{64}\b`)
+
+// callSite is the innermost call whose arguments a line ends within.
+type callSite struct {
+	fn    string // Called expression, e.g. personal.unlockAccount
+	arg   int    // Index of the argument the line ends in
+	start int    // Offset of that argument within the line
+}
+
+// findCall returns the innermost call whose arguments line ends within, nil if
+// there is none. Commas within brackets and string literals are skipped.
+func findCall(line string) *callSite {
+	type bracket struct {
+		call bool
+		site callSite
+	}
+	var (
+		stack []bracket
+		quote byte
+	)
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if quote != 0 {
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'':
+			quote = ch
+		case '(':
+			stack = append(stack, bracket{call: true, site: callSite{fn: callee(line[:i]), start: i + 1}})
+		case '[', '{':
+			stack = append(stack, bracket{})
+		case ')', ']', '}':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			if n := len(stack); n > 0 {
+				stack[n-1].site.arg++
+				stack[n-1].site.start = i + 1
+			}
+		}
+	}
+	if n := len(stack); n > 0 && stack[n-1].call && stack[n-1].site.fn != "" {
+		return &stack[n-1].site
+	}
+	return nil
+}
+
+// callee returns the chain of identifiers line ends with.
+func callee(line string) string {
+	start := len(line)
+	for ; start > 0; start-- {
+		ch := line[start-1]
+		if ch != '.' && ch != '_' && ch != '$' && (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') {
+			break
+		}
+	}
+	return line[start:]
+}
+
+// completeArgument completes the argument of a call line ends in: the paths of
+// files for loadScript, the accounts of the keystore and recently used
+// transaction hashes for the methods declaring such parameters in
+// web3ext.Methods. An empty argument also lists the signature of the method
+// as a hint. It reports false for lines to complete as keywords.
+func (c *Console) completeArgument(line string) (string, []string, bool) {
+	site := findCall(line)
+	if site == nil {
+		return "", nil, false
+	}
+	word := strings.TrimLeft(line[site.start:], " ")
+	if word != "" && word[0] != '"' && word[0] != '\'' && !strings.HasPrefix(word, "0x") {
+		return "", nil, false
+	}
+	head := line[:len(line)-len(word)]
+	if site.fn == "loadScript" {
+		return head, c.completePath(word), true
+	}
+	params := params(site.fn)
+	if params == nil {
+		return "", nil, false
+	}
+	var completions []string
+	if site.arg < len(params) {
+		switch params[site.arg] {
+		case "address", "from", "to":
+			completions = matchQuoted(c.client.Accounts(), word)
+		case "hash":
+			completions = matchQuoted(c.recentHashes(), word)
+		}
+	}
+	if word == "" {
+		// A single completion would be inserted, the hint must only be listed
+		completions = append(completions, fmt.Sprintf("%s(%s)", site.fn, strings.Join(params, ", ")))
+		if len(completions) == 1 {
+			completions = append(completions, "")
+		}
+	}
+	return head, completions, true
+}
+
+// params returns the parameter names of the API method fn, nil if fn is not
+// such a method or its parameters are unknown. The method is looked up by
+// name, fn is not evaluated as members may be backed by gateway requests.
+func params(fn string) []string {
+	m := web3ext.Describe(strings.TrimPrefix(fn, "web3."))
+	if m == nil || m.Property {
+		return nil
+	}
+	return m.ParamNames()
+}
+
+// recentHashes returns the transaction hashes used in the commands of the
+// history, the most recent first.
+func (c *Console) recentHashes() []string {
+	var (
+		hashes []string
+		seen   = make(map[string]bool)
+	)
+	for i := len(c.history.entries) - 1; i >= 0; i-- {
+		for _, hash := range txHashRegexp.FindAllString(c.history.entries[i], -1) {
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes
+}
+
+// completePath completes word, a string literal which may lack its opening
+// quote, to the paths of the files and directories it continues. Relative
+// paths are resolved against the document root like loadScript does.
+func (c *Console) completePath(word string) []string {
+	quote, prefix := splitQuote(word)
+	dir, base := filepath.Split(prefix)
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.docroot, dir)
+	}
+	if path == "" {
+		path = "."
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var completions []string
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if file.IsDir() {
+			completions = append(completions, quote+dir+name+"/")
+		} else {
+			completions = append(completions, quote+dir+name+quote)
+		}
+	}
+	return completions
+}
+
+// matchQuoted returns the values word, a string literal which may lack its
+// opening quote, is a prefix of as string literals.
+func matchQuoted(values []string, word string) []string {
+	quote, prefix := splitQuote(word)
+	var matches []string
+	for _, value := range values {
+		if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+			matches = append(matches, quote+value+quote)
+		}
+	}
+	return matches
+}
+
+// splitQuote splits the opening quote off a string literal, returning a double
+// quote if there is none.
+func splitQuote(word string) (string, string) {
+	if word != "" && (word[0] == '"' || word[0] == '\'') {
+		return word[:1], word[1:]
+	}
+	return `"`, word
+}
@@ -0,0 +1,85 @@
+package console
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCall(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		line string
+		want *callSite
+	}{
+		{`eth.blockNumber`, nil},
+		{`personal.unlockAccount(`, &callSite{fn: "personal.unlockAccount", start: 23}},
+		{`personal.unlockAccount("0x1", "a,b", `, &callSite{fn: "personal.unlockAccount", arg: 2, start: 36}},
+		{`eth.getBalance(eth.accounts[0], `, &callSite{fn: "eth.getBalance", arg: 1, start: 31}},
+		{`crosschain.signTransaction({from: "0x1", to: `, nil},
+		{`eth.getBalance("0x1")`, nil},
+		{`loadScript("scripts/de`, &callSite{fn: "loadScript", start: 11}},
+		{`(`, nil},
+	}
+	for _, test := range tests {
+		assert.Equal(test.want, findCall(test.line), test.line)
+	}
+}
+
+func TestParams(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"address", "password", "duration"}, params("personal.unlockAccount"))
+	assert.Equal([]string{"address", "password", "duration"}, params("web3.personal.unlockAccount"))
+	assert.Equal(params("crosschain.signQueryTransaction"), params("personal.signCrossQueryTransaction"))
+	assert.Nil(params("admin.gateway"))
+	assert.Nil(params("eth.accounts.push"))
+}
+
+func TestCompleteArgument(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputText, &out, &errs)
+	defer done()
+
+	dir, err := ioutil.TempDir("", "astraia-completion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "scripts"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "scripts", "deploy.js"), nil, 0600)
+	ioutil.WriteFile(filepath.Join(dir, ".hidden.js"), nil, 0600)
+	console.docroot = dir
+
+	const hash = "0xef8dadde66af80a228e4899055f2ff202d3e6107904b0f05316ebfcc7a31a850"
+	console.addHistory(`crosschain.status("` + hash + `")`)
+
+	tests := []struct {
+		line        string
+		head        string
+		completions []string
+	}{
+		{`personal.unlockAccount(`, `personal.unlockAccount(`, []string{"personal.unlockAccount(address, password, duration)", ""}},
+		{`personal.unlockAccount("0x`, `personal.unlockAccount(`, nil},
+		{`crosschain.status(`, `crosschain.status(`, []string{`"` + hash + `"`, "crosschain.status(hash)"}},
+		{`crosschain.status('0xef`, `crosschain.status(`, []string{`'` + hash + `'`}},
+		{`loadScript(`, `loadScript(`, []string{`"scripts/`}},
+		{`loadScript("scripts/d`, `loadScript(`, []string{`"scripts/deploy.js"`}},
+		{`loadScript(".h`, `loadScript(`, []string{`".hidden.js"`}},
+	}
+	for _, test := range tests {
+		head, completions, tail := console.AutoCompleteInput(test.line, len(test.line))
+		assert.Equal(test.head, head, test.line)
+		assert.Equal(test.completions, completions, test.line)
+		assert.Equal("", tail, test.line)
+	}
+
+	// expressions within the arguments are completed as keywords
+	head, completions, _ := console.AutoCompleteInput(`eth.getBalance(eth.blockN`, 25)
+	assert.Equal(`eth.getBalance(`, head)
+	assert.Equal([]string{"eth.blockNumber"}, completions)
+}
@@ -17,60 +17,99 @@
 package console
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/DSiSc/astraia/client"
 	"github.com/DSiSc/astraia/jsre"
+	"github.com/DSiSc/astraia/log"
 	"github.com/DSiSc/astraia/web3ext"
 	"github.com/mattn/go-colorable"
 	"io"
-	"io/ioutil"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/peterh/liner"
 	"github.com/robertkrimen/otto"
 )
 
 var (
-	passwordRegexp = regexp.MustCompile(`personal.[nus]`)
+	passwordRegexp = regexp.MustCompile(`personal\.[nusdo]|crosschain\.sign`)
 	onlyWhitespace = regexp.MustCompile(`^\s*$`)
 	exit           = regexp.MustCompile(`^\s*exit\s*;*\s*$`)
 )
 
 // HistoryFile is the file within the data directory to store input scrollback.
+// Config profiles other than the default one append their name to it.
 const HistoryFile = "history"
 
 // DefaultPrompt is the default prompt line prefix to use for user input querying.
 const DefaultPrompt = "> "
 
+// Output formats of evaluated values.
+const (
+	OutputText = "text" // pretty printed JavaScript, colored on terminals
+	OutputJSON = "json" // one line of JSON per value, errors as JSON objects
+)
+
+// maxStreamLine is the longest line Stream accepts.
+const maxStreamLine = 1024 * 1024
+
+// DefaultReconnectInterval is the default interval at which the console checks
+// whether the api gateway is reachable.
+const DefaultReconnectInterval = 10 * time.Second
+
 // Config is the collection of configurations to fine tune the behavior of the
 // JavaScript console.
 type Config struct {
 	DataDir  string       // Data directory to store the console history at
+	Profile  string       // Config profile in use, selecting the history file
 	DocRoot  string       // Filesystem path from where to load JavaScript files from
 	Client   *rpc.Client  // RPC client to execute Ethereum requests through
-	Prompt   string       // Input prompt prefix string (defaults to DefaultPrompt)
+	Prompt   string       // Input prompt template, see PromptContext (defaults to DefaultPrompt)
 	Prompter UserPrompter // Input prompter to allow interactive user feedback (defaults to TerminalPrompter)
 	Printer  io.Writer    // Output writer to serialize any display strings to (defaults to os.Stdout)
 	Preload  []string     // Absolute paths to JavaScript files to preload
+	Output   string       // Output format of evaluated values (defaults to OutputText)
+	Errors   io.Writer    // Output writer of PrintError, used by Evaluate in OutputJSON format (defaults to os.Stderr)
+
+	HistorySize       int           // Number of commands kept in the history (defaults to DefaultHistorySize)
+	ReconnectInterval time.Duration // Interval of the api gateway checks (defaults to DefaultReconnectInterval)
 }
 
 // Console is a JavaScript interpreted runtime environment. It is a fully fledged
 // JavaScript console attached to a running node via an external or in-process RPC
 // client.
 type Console struct {
-	client   *rpc.Client  // RPC client to execute Ethereum requests through
-	jsre     *jsre.JSRE   // JavaScript runtime environment running the interpreter
-	prompt   string       // Input prompt prefix string
-	prompter UserPrompter // Input prompter to allow interactive user feedback
-	histPath string       // Absolute path to the console scrollback history
-	history  []string     // Scroll history maintained by the console
-	printer  io.Writer    // Output writer to serialize any display strings to
+	client   *rpc.Client        // RPC client to execute Ethereum requests through
+	jsre     *jsre.JSRE         // JavaScript runtime environment running the interpreter
+	docroot  string             // Filesystem path loadScript loads JavaScript files from
+	prompt   *template.Template // Input prompt template
+	prompter UserPrompter       // Input prompter to allow interactive user feedback
+	history  *history           // Scroll history maintained by the console
+	printer  io.Writer          // Output writer to serialize any display strings to
+	output   string             // Output format of evaluated values
+	errors   io.Writer          // Output writer of PrintError
+
+	modules   map[string]bool // Api modules mapped into the runtime
+	reconnect time.Duration   // Interval of the api gateway checks
+	stopWatch func()          // Stops the api gateway checks
+	changed   *bool           // Gateway state not yet reported, see gatewayChanged
+
+	profile      string          // Config profile shown by the prompt
+	promptFields map[string]bool // Fields of PromptContext the prompt refers to
+	block        string          // Latest block number shown by the prompt
+	accounts     []string        // Keystore accounts shown by the prompt
+	accountsRead time.Time       // When accounts were read, see keystoreAccounts
+	lock         sync.Mutex      // Protects block, accounts and changed
 }
 
 // New initializes a JavaScript interpreted runtime environment and sets defaults
@@ -86,21 +125,51 @@ func New(config Config) (*Console, error) {
 	if config.Printer == nil {
 		config.Printer = colorable.NewColorableStdout()
 	}
+	switch config.Output {
+	case "":
+		config.Output = OutputText
+	case OutputText, OutputJSON:
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected %s or %s", config.Output, OutputText, OutputJSON)
+	}
+	if config.Errors == nil {
+		config.Errors = os.Stderr
+	}
+	if config.HistorySize <= 0 {
+		config.HistorySize = DefaultHistorySize
+	}
+	if config.ReconnectInterval <= 0 {
+		config.ReconnectInterval = DefaultReconnectInterval
+	}
+	prompt, err := parsePrompt(config.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt %q: %v", config.Prompt, err)
+	}
 	// Initialize the console and return
 	console := &Console{
-		client:   config.Client,
-		jsre:     jsre.New(config.DocRoot, config.Printer),
-		prompt:   config.Prompt,
-		prompter: config.Prompter,
-		printer:  config.Printer,
-		histPath: filepath.Join(config.DataDir, HistoryFile),
+		client:       config.Client,
+		jsre:         jsre.New(config.DocRoot, config.Printer),
+		docroot:      config.DocRoot,
+		prompt:       prompt,
+		promptFields: promptFields(prompt),
+		prompter:     config.Prompter,
+		printer:      config.Printer,
+		output:       config.Output,
+		errors:       config.Errors,
+		history:      loadHistory(historyPath(config.DataDir, config.Profile), config.HistorySize),
+		reconnect:    config.ReconnectInterval,
+		modules:      make(map[string]bool),
+		profile:      config.Profile,
 	}
 	if err := os.MkdirAll(config.DataDir, 0700); err != nil {
 		return nil, err
 	}
+	// An unreachable gateway leaves the console offline until it answers
+	console.client.CheckGateway()
 	if err := console.init(config.Preload); err != nil {
 		return nil, err
 	}
+	console.stopWatch = console.client.WatchGateway(console.reconnect, console.gatewayChanged)
 	return console, nil
 }
 
@@ -134,28 +203,17 @@ func (c *Console) init(preload []string) error {
 	}
 
 	// Load the supported APIs into the JavaScript runtime environment
+	if _, err := c.jsre.Run("var eth = web3.eth; var personal = web3.personal;"); err != nil {
+		return fmt.Errorf("namespace flattening: %v", err)
+	}
 	apis, err := c.client.SupportedModules()
 	if err != nil {
-		return fmt.Errorf("api modules: %v", err)
-	}
-	flatten := "var eth = web3.eth; var personal = web3.personal; "
-	for api := range apis {
-		if api == "web3" {
-			continue // manually mapped or ignore
-		}
-		if file, ok := web3ext.Modules[api]; ok {
-			// Load our extension for the module.
-			if err = c.jsre.Compile(fmt.Sprintf("%s.js", api), file); err != nil {
-				return fmt.Errorf("%s.js: %v", api, err)
-			}
-			flatten += fmt.Sprintf("var %s = web3.%s; ", api, api)
-		} else if obj, err := c.jsre.Run("web3." + api); err == nil && obj.IsObject() {
-			// Enable web3.js built-in extension if available.
-			flatten += fmt.Sprintf("var %s = web3.%s; ", api, api)
-		}
+		// Offline consoles still get the namespaces served locally
+		log.Warn("Failed to retrieve api modules, using the local ones", "err", err)
+		apis = c.client.LocalModules()
 	}
-	if _, err = c.jsre.Run(flatten); err != nil {
-		return fmt.Errorf("namespace flattening: %v", err)
+	if err := c.loadModules(apis); err != nil {
+		return err
 	}
 	// Initialize the global name register (disabled for now)
 	//c.jsre.Run(`var GlobalRegistrar = eth.contract(` + registrar.GlobalRegistrarAbi + `);   registrar = GlobalRegistrar.at("` + registrar.GlobalRegistrarAddr + `");`)
@@ -185,10 +243,18 @@ func (c *Console) init(preload []string) error {
 			if _, err = c.jsre.Run(`jeth.sign = personal.sign;`); err != nil {
 				return fmt.Errorf("personal.sign: %v", err)
 			}
-			//obj.Set("openWallet", bridge.OpenWallet)
+			if _, err = c.jsre.Run(`jeth.newMnemonicWallet = personal.newMnemonicWallet;`); err != nil {
+				return fmt.Errorf("personal.newMnemonicWallet: %v", err)
+			}
+			if _, err = c.jsre.Run(`jeth.deriveAccount = personal.deriveAccount;`); err != nil {
+				return fmt.Errorf("personal.deriveAccount: %v", err)
+			}
+			obj.Set("openWallet", bridge.OpenWallet)
 			obj.Set("unlockAccount", bridge.UnlockAccount)
 			obj.Set("newAccount", bridge.NewAccount)
 			obj.Set("sign", bridge.Sign)
+			obj.Set("newMnemonicWallet", bridge.NewMnemonicWallet)
+			obj.Set("deriveAccount", bridge.DeriveAccount)
 		}
 	}
 	// The admin.sleep and admin.sleepBlocks are offered by the console and not by the RPC layer.
@@ -200,7 +266,15 @@ func (c *Console) init(preload []string) error {
 		obj.Set("sleepBlocks", bridge.SleepBlocks)
 		obj.Set("sleep", bridge.Sleep)
 		obj.Set("clearHistory", c.clearHistory)
+		obj.Set("history", c.showHistory)
+		if history, err := obj.Get("history"); err == nil {
+			history.Object().Set("grep", c.grepHistory)
+		}
 	}
+	if err := c.jsre.Set("help", c.help); err != nil {
+		return fmt.Errorf("help: %v", err)
+	}
+	c.attachHelp()
 	// Preload any JavaScript files before starting the console
 	for _, path := range preload {
 		if err := c.jsre.Exec(path); err != nil {
@@ -213,32 +287,101 @@ func (c *Console) init(preload []string) error {
 	}
 	// Configure the console's input prompter for scrollback and tab completion
 	if c.prompter != nil {
-		if content, err := ioutil.ReadFile(c.histPath); err != nil {
-			c.prompter.SetHistory(nil)
-		} else {
-			c.history = strings.Split(string(content), "\n")
-			c.prompter.SetHistory(c.history)
-		}
+		c.prompter.SetHistory(c.history.entries)
 		c.prompter.SetWordCompleter(c.AutoCompleteInput)
 	}
 	return nil
 }
 
+// loadModules maps the given api modules into the JavaScript runtime, skipping
+// the ones loaded before: the web3ext extension of a module if there is one,
+// the web3.js built-in otherwise.
+func (c *Console) loadModules(apis map[string]string) error {
+	flatten := ""
+	for api := range apis {
+		if api == "web3" || c.modules[api] {
+			continue // manually mapped, ignored or already loaded
+		}
+		if file, ok := web3ext.Modules[api]; ok {
+			// Load our extension for the module.
+			if err := c.jsre.Compile(fmt.Sprintf("%s.js", api), file); err != nil {
+				return fmt.Errorf("%s.js: %v", api, err)
+			}
+			flatten += fmt.Sprintf("var %s = web3.%s; ", api, api)
+		} else if obj, err := c.jsre.Run("web3." + api); err == nil && obj.IsObject() {
+			// Enable web3.js built-in extension if available.
+			flatten += fmt.Sprintf("var %s = web3.%s; ", api, api)
+		}
+		c.modules[api] = true
+	}
+	if _, err := c.jsre.Run(flatten); err != nil {
+		return fmt.Errorf("namespace flattening: %v", err)
+	}
+	return nil
+}
+
 func (c *Console) clearHistory() {
-	c.history = nil
 	c.prompter.ClearHistory()
-	if err := os.Remove(c.histPath); err != nil {
+	if err := c.history.clear(); err != nil {
 		fmt.Fprintln(c.printer, "can't delete history file:", err)
 	} else {
 		fmt.Fprintln(c.printer, "history file deleted")
 	}
 }
 
+// showHistory is admin.history, returning the last n commands of the history,
+// all of them if n is not given.
+func (c *Console) showHistory(call otto.FunctionCall) otto.Value {
+	n, _ := call.Argument(0).ToInteger()
+	return jsStrings(call.Otto, c.history.last(int(n)))
+}
+
+// grepHistory is admin.history.grep, returning the commands of the history
+// matching a regular expression.
+func (c *Console) grepHistory(call otto.FunctionCall) otto.Value {
+	pattern, err := call.Argument(0).ToString()
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	matches, err := c.history.grep(pattern)
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	return jsStrings(call.Otto, matches)
+}
+
+// jsStrings converts list to a JavaScript array.
+func jsStrings(vm *otto.Otto, list []string) otto.Value {
+	if list == nil {
+		list = []string{}
+	}
+	v, err := jsValue(vm, list)
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	return v
+}
+
+// jsValue converts v to a JavaScript value through its JSON encoding.
+func jsValue(vm *otto.Otto, v interface{}) (otto.Value, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	return vm.Call("JSON.parse", nil, string(encoded))
+}
+
 // consoleOutput is an override for the console.log and console.error methods to
 // stream the output into the configured output stream instead of stdout.
 func (c *Console) consoleOutput(call otto.FunctionCall) otto.Value {
 	var output []string
 	for _, argument := range call.ArgumentList {
+		// machine readable output encodes everything but plain strings
+		if c.output == OutputJSON && !argument.IsString() {
+			if encoded, err := jsre.ToJSON(call.Otto, argument); err == nil {
+				argument = encoded
+			}
+		}
 		output = append(output, fmt.Sprintf("%v", argument))
 	}
 	fmt.Fprintln(c.printer, strings.Join(output, " "))
@@ -252,6 +395,10 @@ func (c *Console) AutoCompleteInput(line string, pos int) (string, []string, str
 	if len(line) == 0 || pos == 0 {
 		return "", nil, ""
 	}
+	// Arguments of known methods get their own completions
+	if head, completions, ok := c.completeArgument(line[:pos]); ok {
+		return head, completions, line[pos:]
+	}
 	// Chunck data to relevant part for autocompletion
 	// E.g. in case of nested lines eth.getBalance(eth.coinb<tab><tab>
 	start := pos - 1
@@ -277,12 +424,24 @@ func (c *Console) AutoCompleteInput(line string, pos int) (string, []string, str
 func (c *Console) Welcome() {
 	// Print some generic Geth metadata
 	fmt.Fprintf(c.printer, "Welcome to the astraia JavaScript console!\n\n")
-	c.jsre.Run(`
-		console.log("instance: " + web3.version.node);
-		console.log("coinbase: " + eth.coinbase);
-		console.log("at block: " + eth.blockNumber + " (" + new Date(1000 * eth.getBlock(eth.blockNumber).timestamp) + ")");
-		console.log(" datadir: " + admin.datadir);
-	`)
+	if c.client.Online() {
+		c.jsre.Run(`
+			console.log("instance: " + web3.version.node);
+			console.log("coinbase: " + eth.coinbase);
+			console.log("at block: " + eth.blockNumber + " (" + new Date(1000 * eth.getBlock(eth.blockNumber).timestamp) + ")");
+		`)
+	} else {
+		gw := c.client.Gateway()
+		fmt.Fprintf(c.printer, " offline: api gateway %s unreachable, retrying every %v\n", gw.Endpoint(), c.reconnect)
+		fmt.Fprintf(c.printer, "          keystore, signing and unit conversion work, eth and net calls fail until it answers\n")
+	}
+	c.jsre.Run(`console.log(" datadir: " + admin.datadir);`)
+	if network := c.client.Network(); network != nil {
+		fmt.Fprintf(c.printer, " network: %s\n", network)
+		if network.Explorer != "" {
+			fmt.Fprintf(c.printer, "explorer: %s\n", network.Explorer)
+		}
+	}
 	// List all the supported modules for the user to call
 	if apis, err := c.client.SupportedModules(); err == nil {
 		modules := make([]string, 0, len(apis))
@@ -295,22 +454,86 @@ func (c *Console) Welcome() {
 	fmt.Fprintln(c.printer)
 }
 
+// gatewayChanged records that the console went offline or came back online.
+// It is called by the gateway watcher, the change is reported by the input
+// loop before the next prompt, see reportGateway.
+func (c *Console) gatewayChanged(online bool) {
+	c.lock.Lock()
+	c.changed = &online
+	c.lock.Unlock()
+}
+
+// reportGateway tells the user that the console went offline or came back
+// online since the last prompt, loading the modules of the gateway in the
+// latter case.
+func (c *Console) reportGateway() {
+	c.lock.Lock()
+	changed := c.changed
+	c.changed = nil
+	c.lock.Unlock()
+	if changed == nil {
+		return
+	}
+	gw := c.client.Gateway()
+	if *changed {
+		fmt.Fprintf(c.printer, "api gateway %s reachable again, console is online\n", gw.Endpoint())
+		// the gateway may serve modules which were unknown while offline
+		apis, err := c.client.SupportedModules()
+		if err == nil {
+			err = c.loadModules(apis)
+		}
+		if err != nil {
+			log.Warn("Failed to load api modules", "err", err)
+		}
+		c.attachHelp()
+	} else {
+		fmt.Fprintf(c.printer, "api gateway %s unreachable, console is offline\n", gw.Endpoint())
+	}
+}
+
 // Evaluate executes code and pretty prints the result to the specified output
-// stream.
-func (c *Console) Evaluate(statement string) error {
+// stream. It returns the error thrown by the code.
+func (c *Console) Evaluate(statement string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(c.printer, "[native] error: %v\n", r)
+			err = fmt.Errorf("[native] error: %v", r)
+			if c.output == OutputJSON {
+				c.PrintError(err)
+				return
+			}
+			fmt.Fprintln(c.printer, err)
 		}
 	}()
+	if c.output == OutputJSON {
+		err := c.jsre.EvaluateJSON(statement, c.printer)
+		if err != nil {
+			c.PrintError(err)
+		}
+		return err
+	}
 	return c.jsre.Evaluate(statement, c.printer)
 }
 
+// PrintError writes err to the error writer: a JSON object with the message in
+// the error field in OutputJSON format, the plain message otherwise.
+func (c *Console) PrintError(err error) {
+	failure := err.Error()
+	if ottoErr, ok := err.(*otto.Error); ok {
+		failure = ottoErr.String()
+	}
+	if c.output != OutputJSON {
+		fmt.Fprintln(c.errors, jsre.ErrorColor("%s", failure))
+		return
+	}
+	out, _ := json.Marshal(map[string]string{"error": failure})
+	fmt.Fprintln(c.errors, string(out))
+}
+
 // Interactive starts an interactive user session, where input is propted from
 // the configured user prompter.
 func (c *Console) Interactive() {
 	var (
-		prompt    = c.prompt          // Current prompt line (used for multi-line inputs)
+		prompt    = ""                // Current prompt line (used for multi-line inputs)
 		indents   = 0                 // Current number of input indents (used for multi-line inputs)
 		input     = ""                // Current user input
 		scheduler = make(chan string) // Channel to send the next prompt on and receive the input
@@ -323,7 +546,7 @@ func (c *Console) Interactive() {
 			if err != nil {
 				// In case of an error, either clear the prompt or fail
 				if err == liner.ErrPromptAborted { // ctrl-C
-					prompt, indents, input = c.prompt, 0, ""
+					indents, input = 0, ""
 					scheduler <- ""
 					continue
 				}
@@ -338,9 +561,18 @@ func (c *Console) Interactive() {
 	abort := make(chan os.Signal, 1)
 	signal.Notify(abort, syscall.SIGINT, syscall.SIGTERM)
 
+	// Keep the block number of the prompt up to date if it shows it
+	if c.promptFields["Block"] {
+		stop := c.watchBlock(blockInterval)
+		defer stop()
+	}
 	// Start sending prompts to the user and reading back inputs
 	for {
 		// Send the next prompt, triggering an input read and process the result
+		if indents <= 0 {
+			c.reportGateway()
+			prompt = c.Prompt()
+		}
 		scheduler <- prompt
 		select {
 		case <-abort:
@@ -360,21 +592,12 @@ func (c *Console) Interactive() {
 			input += line + "\n"
 
 			indents = countIndents(input)
-			if indents <= 0 {
-				prompt = c.prompt
-			} else {
+			if indents > 0 {
 				prompt = strings.Repeat(".", indents*3) + " "
 			}
 			// If all the needed lines are present, save the command and run
 			if indents <= 0 {
-				if len(input) > 0 && input[0] != ' ' && !passwordRegexp.MatchString(input) {
-					if command := strings.TrimSpace(input); len(c.history) == 0 || command != c.history[len(c.history)-1] {
-						c.history = append(c.history, command)
-						if c.prompter != nil {
-							c.prompter.AppendHistory(command)
-						}
-					}
-				}
+				c.addHistory(input)
 				c.Evaluate(input)
 				input = ""
 			}
@@ -382,6 +605,66 @@ func (c *Console) Interactive() {
 	}
 }
 
+// addHistory appends the input to the scrollback history, unless it starts
+// with a space, repeats the last command or may contain a password.
+func (c *Console) addHistory(input string) {
+	if len(input) == 0 || input[0] == ' ' || passwordRegexp.MatchString(input) {
+		return
+	}
+	command := strings.TrimSpace(input)
+	added, err := c.history.add(command)
+	if err != nil {
+		log.Warn("Failed to append to the history file", "err", err)
+	}
+	if added && c.prompter != nil {
+		c.prompter.AppendHistory(command)
+	}
+}
+
+// Stream evaluates the statements read from r, e.g. a script piped into the
+// console. Statements may span several lines like in Interactive and exit
+// ends the input. Stream stops at the first statement which fails unless
+// continueOnError is set, and returns the first error.
+func (c *Console) Stream(r io.Reader, continueOnError bool) error {
+	var (
+		scanner = bufio.NewScanner(r)
+		input   = "" // Current statement, possibly spanning several lines
+		failure error
+	)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLine)
+	evaluate := func() bool {
+		err := c.Evaluate(input)
+		input = ""
+		if err != nil && failure == nil {
+			failure = err
+		}
+		return err == nil || continueOnError
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if input == "" && onlyWhitespace.MatchString(line) {
+			continue
+		}
+		if input == "" && exit.MatchString(line) {
+			return failure
+		}
+		input += line + "\n"
+		if countIndents(input) <= 0 && !evaluate() {
+			return failure
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		err = fmt.Errorf("reading input: %v", err)
+		c.PrintError(err)
+		return err
+	}
+	// an unterminated statement fails with a syntax error
+	if input != "" {
+		evaluate()
+	}
+	return failure
+}
+
 // countIndents returns the number of identations for the given input.
 // In case of invalid input such as var a = } the result can be negative.
 func countIndents(input string) int {
@@ -430,12 +713,40 @@ func (c *Console) Execute(path string) error {
 	return c.jsre.Exec(path)
 }
 
+// Run executes the JavaScript file at path as a script: args are passed in
+// process.argv after the interpreter and the script path, and process.exit ends
+// it. Run waits for the timers of the script and stops the runtime, it returns
+// the *jsre.ExitError of process.exit or the error the script threw.
+func (c *Console) Run(path string, args []string) error {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	// plain JavaScript arrays and objects, unlike bound Go values
+	argv, _ := json.Marshal(append([]string{"astraia", path}, args...))
+	environ, _ := json.Marshal(env)
+	if _, err := c.jsre.Run(fmt.Sprintf("var process = {argv: %s, env: %s};", argv, environ)); err != nil {
+		return fmt.Errorf("process: %v", err)
+	}
+	process, _ := c.jsre.Get("process")
+	process.Object().Set("exit", c.jsre.Exit)
+	if err := c.jsre.Exec(path); err != nil {
+		if ottoErr, ok := err.(*otto.Error); ok {
+			return errors.New(ottoErr.String())
+		}
+		return err
+	}
+	return c.jsre.Wait()
+}
+
 // Stop cleans up the console and terminates the runtime environment.
 func (c *Console) Stop(graceful bool) error {
-	if err := ioutil.WriteFile(c.histPath, []byte(strings.Join(c.history, "\n")), 0600); err != nil {
-		return err
+	if c.stopWatch != nil {
+		c.stopWatch()
 	}
-	if err := os.Chmod(c.histPath, 0600); err != nil { // Force 0600, even if it was different previously
+	if err := c.history.save(); err != nil {
 		return err
 	}
 	c.jsre.Stop(graceful)
@@ -0,0 +1,130 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DSiSc/astraia/client"
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConsole creates a console whose gateway is unreachable, writing to
+// out and errs.
+func newTestConsole(t *testing.T, output string, out, errs *bytes.Buffer) (*Console, func()) {
+	dir, err := ioutil.TempDir("", "astraia-console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := config.Default().ApiGateway
+	gw.URL = "http://127.0.0.1:1"
+	c, err := rpc.DialWithOptions(context.Background(), gw.URL, rpc.Options{
+		Gateway: &gw,
+		Logger:  log.New(ioutil.Discard, log.LvlInfo, log.FormatText),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	console, err := New(Config{
+		DataDir:  dir,
+		Client:   c,
+		Prompter: NonInteractive,
+		Printer:  out,
+		Output:   output,
+		Errors:   errs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return console, func() {
+		console.Stop(false)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStream(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputJSON, &out, &errs)
+	defer done()
+
+	input := `
+var total = 0;
+function add(n) {
+	total += n;
+	return total;
+}
+add(2)
+undefinedFunction()
+add(3)
+`
+	assert.NotNil(console.Stream(strings.NewReader(input), false))
+	assert.Equal("null\nnull\n2\n", out.String())
+	assert.Contains(errs.String(), `{"error":"ReferenceError: 'undefinedFunction' is not defined`)
+
+	out.Reset()
+	assert.NotNil(console.Stream(strings.NewReader(input), true))
+	assert.Equal("null\nnull\n2\n5\n", out.String())
+
+	// exit ends the input
+	out.Reset()
+	assert.Nil(console.Stream(strings.NewReader("add(1)\nexit\nadd(1)\n"), false))
+	assert.Equal("6\n", out.String())
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputJSON, &out, &errs)
+	defer done()
+
+	assert.Nil(console.Evaluate(`({unit: web3.toWei(1, "ether")})`))
+	assert.Equal(`{"unit":"1000000000000000000"}`+"\n", out.String())
+
+	// the namespaces of the gateway fail while offline
+	out.Reset()
+	assert.NotNil(console.Evaluate(`eth.blockNumber`))
+	assert.Contains(errs.String(), "unavailable offline")
+	assert.Equal("", out.String())
+}
+
+func TestReportGateway(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputText, &out, &errs)
+	defer done()
+	out.Reset()
+
+	// changes of the watcher wait for the input loop, the last one wins
+	console.gatewayChanged(true)
+	console.gatewayChanged(false)
+	assert.Equal("", out.String())
+	console.reportGateway()
+	assert.Equal("api gateway http://127.0.0.1:1 unreachable, console is offline\n", out.String())
+
+	out.Reset()
+	console.reportGateway()
+	assert.Equal("", out.String())
+}
+
+func TestCountIndents(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{`function f() {`, 1},
+		{`var s = "{("; f(`, 1},
+		{`var s = 'a\'{'`, 0},
+		{`}`, -1},
+	}
+	for _, test := range tests {
+		if got := countIndents(test.input); got != test.want {
+			t.Errorf("%s: expected %d indents, got %d", test.input, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package console
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DSiSc/astraia/web3ext"
+	"github.com/robertkrimen/otto"
+)
+
+// helpUsage is printed by help() without argument, followed by the namespaces.
+const helpUsage = `Usage: help(method), help("namespace.method") or method.help for a method,
+help("namespace") for the methods of a namespace.`
+
+// attachHelp sets the help property of the API methods defined in the runtime
+// to their description. It runs again whenever methods are (re)defined.
+func (c *Console) attachHelp() {
+	c.jsre.Do(func(vm *otto.Otto) {
+		for i := range web3ext.Methods {
+			m := &web3ext.Methods[i]
+			if m.Property {
+				continue // evaluating properties calls the api
+			}
+			for _, name := range append([]string{m.Name}, m.Aliases...) {
+				fn, err := vm.Run(name)
+				if err != nil || !fn.IsFunction() {
+					continue
+				}
+				if help, err := jsValue(vm, m); err == nil {
+					fn.Object().Set("help", help)
+				}
+			}
+		}
+	})
+}
+
+// help is the help function of the console. Without argument it lists the
+// namespaces, given a namespace its methods and given a method or its name
+// the description of the method.
+func (c *Console) help(call otto.FunctionCall) otto.Value {
+	arg := call.Argument(0)
+	if !arg.IsDefined() {
+		fmt.Fprintf(c.printer, "%s\n\nNamespaces: %s\n", helpUsage, strings.Join(helpNamespaces(), ", "))
+		return otto.UndefinedValue()
+	}
+	if m := describe(arg); m != nil {
+		fmt.Fprint(c.printer, m.Text())
+		return otto.UndefinedValue()
+	}
+	if arg.IsString() {
+		var methods []web3ext.Method
+		for _, m := range web3ext.Methods {
+			if strings.HasPrefix(m.Name, arg.String()+".") {
+				methods = append(methods, m)
+			}
+		}
+		if len(methods) > 0 {
+			fmt.Fprint(c.printer, web3ext.Index(methods))
+			return otto.UndefinedValue()
+		}
+	}
+	return throwJSException(fmt.Sprintf("no help for %v, see help()", arg))
+}
+
+// describe returns the description of a method of the runtime or of the
+// method named by a string, nil if there is none.
+func describe(arg otto.Value) *web3ext.Method {
+	switch {
+	case arg.IsString():
+		return web3ext.Describe(arg.String())
+	case arg.IsFunction():
+		if help, _ := arg.Object().Get("help"); help.IsObject() {
+			if name, _ := help.Object().Get("name"); name.IsString() {
+				return web3ext.Describe(name.String())
+			}
+		}
+		if call, _ := arg.Object().Get("call"); call.IsString() {
+			return web3ext.Describe(call.String())
+		}
+	}
+	return nil
+}
+
+// helpNamespaces returns the namespaces of the described methods.
+func helpNamespaces() []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, m := range web3ext.Methods {
+		if ns := strings.SplitN(m.Name, ".", 2)[0]; !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
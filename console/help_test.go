@@ -0,0 +1,40 @@
+package console
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelp(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputJSON, &out, &errs)
+	defer done()
+
+	assert.Nil(console.Evaluate(`help(crosschain.status)`))
+	assert.True(strings.HasPrefix(out.String(), "crosschain.status(hash)\n\nTrack a cross-chain transaction"), out.String())
+	out.Reset()
+
+	// aliases and methods replaced by the console keep their help
+	assert.Nil(console.Evaluate(`personal.signCrossTransaction.help.name`))
+	assert.Equal("\"crosschain.signTransaction\"\n", out.String())
+	out.Reset()
+	assert.Nil(console.Evaluate(`help("admin.gateway")`))
+	assert.Contains(out.String(), "JSON-RPC method: admin_gateway\n")
+	out.Reset()
+
+	assert.Nil(console.Evaluate(`help("lightclient")`))
+	assert.Contains(out.String(), "lightclient.setGateway(url)")
+	assert.NotContains(out.String(), "admin.")
+	out.Reset()
+
+	assert.Nil(console.Evaluate(`help()`))
+	assert.Contains(out.String(), "Namespaces: admin, crosschain, debug, eth")
+	out.Reset()
+
+	assert.NotNil(console.Evaluate(`help("unknown")`))
+	assert.Contains(errs.String(), "no help for unknown")
+}
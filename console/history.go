@@ -0,0 +1,131 @@
+package console
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultHistorySize is the default number of commands kept in the history.
+const DefaultHistorySize = 1000
+
+// history is the scrollback history of a console. Commands are appended to
+// the history file as they are entered, so that a crashing console loses
+// none and consoles sharing the file keep each other's commands, and the file
+// is cut down to the size cap when loaded and saved.
+type history struct {
+	path    string
+	size    int
+	entries []string
+}
+
+// historyPath returns the history file of a config profile in the data
+// directory.
+func historyPath(datadir, profile string) string {
+	if profile == "" {
+		return filepath.Join(datadir, HistoryFile)
+	}
+	return filepath.Join(datadir, HistoryFile+"-"+profile)
+}
+
+// loadHistory reads the history file at path, keeping the last size commands.
+// A missing file yields an empty history.
+func loadHistory(path string, size int) *history {
+	h := &history{path: path, size: size}
+	h.entries, _ = readHistory(path)
+	h.trim()
+	return h
+}
+
+// readHistory returns the commands of the history file at path.
+func readHistory(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+func (h *history) trim() {
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+}
+
+// add appends command to the history and the history file. It reports false
+// for a command repeating the last one, which is not added.
+func (h *history) add(command string) (bool, error) {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == command {
+		return false, nil
+	}
+	h.entries = append(h.entries, command)
+	h.trim()
+
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return true, err
+	}
+	defer f.Close()
+	_, err = f.WriteString(command + "\n")
+	return true, err
+}
+
+// save cuts the history file down to the size cap. The file is read again
+// rather than rewritten from the entries, as other consoles of the same
+// profile append to it as well; it already holds the commands of this one.
+func (h *history) save() error {
+	entries, err := readHistory(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(entries) > h.size {
+		entries = entries[len(entries)-h.size:]
+	}
+	content := strings.Join(entries, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := ioutil.WriteFile(h.path, []byte(content), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(h.path, 0600) // Force 0600, even if it was different previously
+}
+
+// clear drops all commands and deletes the history file.
+func (h *history) clear() error {
+	h.entries = nil
+	return os.Remove(h.path)
+}
+
+// last returns the n most recent commands, all of them if n is not positive.
+func (h *history) last(n int) []string {
+	if n <= 0 || n > len(h.entries) {
+		n = len(h.entries)
+	}
+	return append([]string(nil), h.entries[len(h.entries)-n:]...)
+}
+
+// grep returns the commands matching the regular expression pattern.
+func (h *history) grep(pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, command := range h.entries {
+		if re.MatchString(command) {
+			matches = append(matches, command)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,121 @@
+package console
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "astraia-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := historyPath(dir, "")
+	assert.Equal(filepath.Join(dir, "history"), path)
+	assert.Equal(filepath.Join(dir, "history-testnet"), historyPath(dir, "testnet"))
+
+	h := loadHistory(path, 3)
+	assert.Empty(h.last(0))
+	for _, command := range []string{"eth.accounts", "eth.blockNumber", "eth.getBalance(eth.accounts[0])"} {
+		added, err := h.add(command)
+		assert.True(added)
+		assert.Nil(err)
+	}
+	added, err := h.add("eth.getBalance(eth.accounts[0])")
+	assert.False(added)
+	assert.Nil(err)
+
+	// commands are appended as they are added, the size cap applies on load
+	h.add("admin.gateway")
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal("eth.accounts\neth.blockNumber\neth.getBalance(eth.accounts[0])\nadmin.gateway\n", string(content))
+	assert.Equal([]string{"eth.blockNumber", "eth.getBalance(eth.accounts[0])", "admin.gateway"}, loadHistory(path, 3).entries)
+
+	assert.Equal([]string{"eth.getBalance(eth.accounts[0])", "admin.gateway"}, h.last(2))
+	assert.Equal(h.entries, h.last(10))
+	matches, err := h.grep(`^eth\.`)
+	assert.Nil(err)
+	assert.Equal([]string{"eth.blockNumber", "eth.getBalance(eth.accounts[0])"}, matches)
+	_, err = h.grep("(")
+	assert.NotNil(err)
+
+	assert.Nil(h.save())
+	content, _ = ioutil.ReadFile(path)
+	assert.Equal("eth.blockNumber\neth.getBalance(eth.accounts[0])\nadmin.gateway\n", string(content))
+	info, _ := os.Stat(path)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	assert.Nil(h.clear())
+	assert.Empty(h.last(0))
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestHistorySharedFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "astraia-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := historyPath(dir, "testnet")
+
+	// two consoles of the same profile, the first one stopping last
+	first, second := loadHistory(path, 3), loadHistory(path, 3)
+	first.add("eth.accounts")
+	second.add("eth.blockNumber")
+	second.add("admin.gateway")
+	assert.Nil(second.save())
+	first.add("eth.syncing")
+	assert.Nil(first.save())
+
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal("eth.blockNumber\nadmin.gateway\neth.syncing\n", string(content))
+
+	// a cleared history is not written back
+	assert.Nil(first.clear())
+	assert.Nil(second.save())
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestPasswordRegexp(t *testing.T) {
+	assert := assert.New(t)
+	for _, input := range []string{
+		`personal.newAccount("secret")`,
+		`personal.unlockAccount(eth.accounts[0], "secret")`,
+		`personal.signTransaction(tx, "secret")`,
+		`crosschain.signTransaction(from, to, value, "secret")`,
+		`crosschain.signQueryTransaction(from, to, value, "secret")`,
+	} {
+		assert.True(passwordRegexp.MatchString(input), input)
+	}
+	for _, input := range []string{`personal.listAccounts`, `crosschain.status(hash)`, `eth.accounts`} {
+		assert.False(passwordRegexp.MatchString(input), input)
+	}
+}
+
+func TestAdminHistory(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputJSON, &out, &errs)
+	defer done()
+
+	for _, input := range []string{"eth.blockNumber\n", " personal.listAccounts\n", "personal.unlockAccount(eth.coinbase, \"secret\")\n"} {
+		console.addHistory(input)
+	}
+
+	console.Evaluate(`admin.history()`)
+	console.Evaluate(`admin.history.grep("^eth")`)
+	console.Evaluate(`admin.history.grep("(")`)
+	assert.Equal("[\"eth.blockNumber\"]\n[\"eth.blockNumber\"]\n", out.String())
+	assert.Contains(errs.String(), "error parsing regexp")
+}
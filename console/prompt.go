@@ -0,0 +1,236 @@
+package console
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/DSiSc/astraia/log"
+	"github.com/robertkrimen/otto"
+)
+
+// blockInterval is the interval at which the block number of the prompt is
+// refreshed.
+const blockInterval = 5 * time.Second
+
+// accountsInterval is the interval at which the keystore accounts of the
+// prompt are reread.
+const accountsInterval = 5 * time.Second
+
+// PromptContext is the data the prompt template is rendered with before each
+// input. Besides the fields the template may use the short function, which
+// abbreviates addresses and hashes.
+type PromptContext struct {
+	Profile string // Config profile in use, empty for none
+	Network string // Network in use, empty for none
+	Gateway string // Endpoint of the api gateway
+	Online  bool   // Whether the api gateway is reachable
+	Block   string // Latest block number, empty until known
+	Account string // eth.defaultAccount, the first account of the keystore if unset
+}
+
+// Status returns online or offline.
+func (p *PromptContext) Status() string {
+	if p.Online {
+		return "online"
+	}
+	return "offline"
+}
+
+var promptFuncs = template.FuncMap{
+	"short": shortHex,
+}
+
+// shortHex abbreviates hexadecimal strings longer than 12 characters to their
+// first 6 and last 4 characters, e.g. 0x1b19…95d4.
+func shortHex(s string) string {
+	if len(s) <= 12 {
+		return s
+	}
+	return s[:6] + "…" + s[len(s)-4:]
+}
+
+// parsePrompt parses the prompt template, checking the fields it refers to.
+func parsePrompt(text string) (*template.Template, error) {
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(ioutil.Discard, &PromptContext{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// promptFields returns the fields of PromptContext the prompt template refers
+// to. A template passing on the whole context, e.g. to printf, refers to all.
+func promptFields(tmpl *template.Template) map[string]bool {
+	var (
+		fields = make(map[string]bool)
+		all    bool
+		walk   func(node parse.Node, root bool)
+	)
+	// root is unset within with and range, where dot is not the context
+	walk = func(node parse.Node, root bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, node := range n.Nodes {
+					walk(node, root)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, root)
+		case *parse.IfNode:
+			walk(n.Pipe, root)
+			walk(n.List, root)
+			walk(n.ElseList, root)
+		case *parse.RangeNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.WithNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.TemplateNode:
+			walk(n.Pipe, root)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd, root)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, root)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, root)
+		case *parse.FieldNode:
+			if root {
+				fields[n.Ident[0]] = true
+			}
+		case *parse.DotNode:
+			all = all || root
+		case *parse.VariableNode:
+			all = true
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Root, true)
+		}
+	}
+	if all {
+		typ := reflect.TypeOf(PromptContext{})
+		for i := 0; i < typ.NumField(); i++ {
+			fields[typ.Field(i).Name] = true
+		}
+	}
+	return fields
+}
+
+// Prompt returns the prompt template rendered with the current context, the
+// DefaultPrompt if rendering fails.
+func (c *Console) Prompt() string {
+	var prompt strings.Builder
+	if err := c.prompt.Execute(&prompt, c.promptContext()); err != nil {
+		log.Debug("Failed to render the prompt", "err", err)
+		return DefaultPrompt
+	}
+	return prompt.String()
+}
+
+func (c *Console) promptContext() *PromptContext {
+	gw := c.client.Gateway()
+	ctx := &PromptContext{
+		Profile: c.profile,
+		Gateway: gw.Endpoint(),
+		Online:  c.client.Online(),
+	}
+	if c.promptFields["Account"] {
+		ctx.Account = c.defaultAccount()
+	}
+	if network := c.client.Network(); network != nil {
+		ctx.Network = network.Name
+	}
+	c.lock.Lock()
+	ctx.Block = c.block
+	c.lock.Unlock()
+	return ctx
+}
+
+// defaultAccount returns eth.defaultAccount, or the first account of the
+// keystore if it is not set.
+func (c *Console) defaultAccount() string {
+	var account string
+	c.jsre.Do(func(vm *otto.Otto) {
+		if v, err := vm.Run("eth.defaultAccount"); err == nil && v.IsString() {
+			account = v.String()
+		}
+	})
+	if account == "" {
+		if accounts := c.keystoreAccounts(); len(accounts) > 0 {
+			account = accounts[0]
+		}
+	}
+	return account
+}
+
+// keystoreAccounts returns the accounts of the keystore, reread at most every
+// accountsInterval.
+func (c *Console) keystoreAccounts() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.accountsRead.IsZero() || time.Since(c.accountsRead) >= accountsInterval {
+		c.accounts, c.accountsRead = c.client.Accounts(), time.Now()
+	}
+	return c.accounts
+}
+
+// watchBlock refreshes the block number of the prompt every interval until the
+// returned function is called.
+func (c *Console) watchBlock(interval time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.updateBlock()
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(quit) }) }
+}
+
+// updateBlock fetches the latest block number while the console is online.
+func (c *Console) updateBlock() {
+	if !c.client.Online() {
+		return
+	}
+	var number string
+	if err := c.client.Call(&number, "eth_blockNumber"); err != nil {
+		log.Debug("Failed to retrieve the block number", "err", err)
+		return
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(number, "0x"), 16, 64)
+	if err != nil {
+		log.Debug("Invalid block number", "number", number)
+		return
+	}
+	c.lock.Lock()
+	c.block = fmt.Sprint(n)
+	c.lock.Unlock()
+}
@@ -0,0 +1,82 @@
+package console
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrompt(t *testing.T) {
+	assert := assert.New(t)
+	for _, text := range []string{DefaultPrompt, "{{.Network}} #{{.Block}}> ", "{{if .Online}}{{short .Account}}{{else}}offline{{end}}> "} {
+		_, err := parsePrompt(text)
+		assert.Nil(err, text)
+	}
+	_, err := parsePrompt("{{.Height}}> ")
+	assert.NotNil(err)
+	_, err = parsePrompt("{{.Block> ")
+	assert.NotNil(err)
+	_, err = parsePrompt("{{long .Account}}> ")
+	assert.NotNil(err)
+}
+
+func TestShortHex(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("0x1b19…95d4", shortHex("0x1b192c4e353dc40871066023bf37fc632f1695d4"))
+	assert.Equal("0x1b4", shortHex("0x1b4"))
+	assert.Equal("", shortHex(""))
+}
+
+func TestPromptFields(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		text           string
+		block, account bool
+	}{
+		{DefaultPrompt, false, false},
+		{"{{.Network}} #{{.Block}}> ", true, false},
+		{"{{if .Online}}{{short .Account}}{{else}}offline{{end}}> ", false, true},
+		{"{{with .Profile}}{{.}} {{end}}{{.Status}}> ", false, false},
+		{`{{printf "%v" .}}> `, true, true},
+	}
+	for _, test := range tests {
+		prompt, err := parsePrompt(test.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields := promptFields(prompt)
+		assert.Equal(test.block, fields["Block"], test.text)
+		assert.Equal(test.account, fields["Account"], test.text)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	assert := assert.New(t)
+	var out, errs bytes.Buffer
+	console, done := newTestConsole(t, OutputText, &out, &errs)
+	defer done()
+
+	assert.Equal(DefaultPrompt, console.Prompt())
+
+	prompt, err := parsePrompt("{{.Profile}} {{.Status}} #{{.Block}} {{short .Account}}> ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	console.prompt, console.promptFields, console.profile = prompt, promptFields(prompt), "testnet"
+	assert.Equal("testnet offline #  > ", console.Prompt())
+
+	// the block number is only fetched while online
+	console.updateBlock()
+	assert.Equal("", console.block)
+
+	console.block = "436"
+	console.Evaluate(`eth.defaultAccount = "0x1b192c4e353dc40871066023bf37fc632f1695d4"`)
+	assert.Equal("testnet offline #436 0x1b19…95d4> ", console.Prompt())
+
+	// the keystore accounts are cached between prompts
+	console.Evaluate(`eth.defaultAccount = undefined`)
+	console.accounts, console.accountsRead = []string{"0x5e4bd1e8d43f2a4c3b4f2bd3c8e7b1a2d3f4e5a6"}, time.Now()
+	assert.Equal("testnet offline #436 0x5e4b…e5a6> ", console.Prompt())
+}
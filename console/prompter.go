@@ -17,7 +17,9 @@
 package console
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/peterh/liner"
@@ -27,6 +29,20 @@ import (
 // Only this reader may be used for input because it keeps an internal buffer.
 var Stdin = newTerminalPrompter()
 
+// NonInteractive is the prompter of consoles reading their input from a pipe
+// or file. It fails all prompts instead of consuming the input.
+var NonInteractive UserPrompter = nonInteractivePrompter{}
+
+// ErrNotInteractive is returned by the prompts of NonInteractive.
+var ErrNotInteractive = errors.New("standard input is not a terminal, cannot prompt")
+
+// StdinIsTerminal reports whether standard input is a terminal rather than a
+// pipe or a file.
+func StdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // UserPrompter defines the methods needed by the console to prompt the user for
 // various types of inputs.
 type UserPrompter interface {
@@ -170,3 +186,24 @@ func (p *terminalPrompter) ClearHistory() {
 func (p *terminalPrompter) SetWordCompleter(completer WordCompleter) {
 	p.State.SetWordCompleter(liner.WordCompleter(completer))
 }
+
+// nonInteractivePrompter is a UserPrompter failing every prompt, without
+// scrollback history and completion.
+type nonInteractivePrompter struct{}
+
+func (nonInteractivePrompter) PromptInput(prompt string) (string, error) {
+	return "", ErrNotInteractive
+}
+
+func (nonInteractivePrompter) PromptPassword(prompt string) (string, error) {
+	return "", ErrNotInteractive
+}
+
+func (nonInteractivePrompter) PromptConfirm(prompt string) (bool, error) {
+	return false, ErrNotInteractive
+}
+
+func (nonInteractivePrompter) SetHistory(history []string)              {}
+func (nonInteractivePrompter) AppendHistory(command string)             {}
+func (nonInteractivePrompter) ClearHistory()                            {}
+func (nonInteractivePrompter) SetWordCompleter(completer WordCompleter) {}
@@ -0,0 +1,142 @@
+// Package hdwallet implements BIP-39 mnemonic generation and BIP-32/44
+// hierarchical deterministic key derivation for keystore accounts.
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultBaseDerivationPath is the base path from which accounts are derived,
+// the index of an account being appended as the last component.
+const DefaultBaseDerivationPath = "m/44'/60'/0'/0"
+
+// DefaultEntropyBits is the entropy used for new mnemonics (24 words).
+const DefaultEntropyBits = 256
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// DerivationPath represents the computer friendly version of a hierarchical
+// deterministic wallet account derivation path.
+type DerivationPath []uint32
+
+// ParseDerivationPath converts a user specified derivation path string to the
+// internal binary representation. Hardened components are marked with a
+// trailing apostrophe, e.g. m/44'/60'/0'/0.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	var result DerivationPath
+
+	components := strings.Split(strings.TrimSpace(path), "/")
+	switch {
+	case len(components) == 0 || components[0] == "":
+		return nil, errors.New("empty derivation path")
+	case strings.TrimSpace(components[0]) == "m":
+		components = components[1:]
+	default:
+		return nil, fmt.Errorf("derivation path %q must start with m/", path)
+	}
+	if len(components) == 0 {
+		return nil, errors.New("empty derivation path")
+	}
+	for _, component := range components {
+		component = strings.TrimSpace(component)
+
+		var value uint32
+		if strings.HasSuffix(component, "'") {
+			value = hdkeychain.HardenedKeyStart
+			component = strings.TrimSpace(strings.TrimSuffix(component, "'"))
+		}
+		bigval, ok := new(big.Int).SetString(component, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid component: %s", component)
+		}
+		max := math.MaxUint32 - value
+		if bigval.Sign() < 0 || bigval.Cmp(big.NewInt(int64(max))) > 0 {
+			if value == 0 {
+				return nil, fmt.Errorf("component %v out of allowed range [0, %d]", bigval, max)
+			}
+			return nil, fmt.Errorf("component %v out of allowed hardened range [0, %d]", bigval, max)
+		}
+		value += uint32(bigval.Uint64())
+
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// String implements the stringer interface, converting a binary derivation path
+// to its canonical representation.
+func (path DerivationPath) String() string {
+	result := "m"
+	for _, component := range path {
+		var hardened bool
+		if component >= hdkeychain.HardenedKeyStart {
+			component -= hdkeychain.HardenedKeyStart
+			hardened = true
+		}
+		result = fmt.Sprintf("%s/%d", result, component)
+		if hardened {
+			result += "'"
+		}
+	}
+	return result
+}
+
+// Child returns a copy of the path with index appended as last component.
+func (path DerivationPath) Child(index uint32) DerivationPath {
+	child := make(DerivationPath, len(path), len(path)+1)
+	copy(child, path)
+	return append(child, index)
+}
+
+// NewMnemonic generates a new random mnemonic sentence with the given amount
+// of entropy bits (a multiple of 32 between 128 and 256).
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewSeed validates the mnemonic and returns the BIP-39 seed derived from it
+// and the optional passphrase.
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic = normalize(mnemonic)
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// DeriveKey derives the private key found at path below the master key of the
+// given seed.
+func DeriveKey(seed []byte, path DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range path {
+		if key, err = key.Child(n); err != nil {
+			return nil, err
+		}
+	}
+	priv, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	return priv.ToECDSA(), nil
+}
+
+// normalize collapses the whitespace of a user supplied mnemonic.
+func normalize(mnemonic string) string {
+	return strings.Join(strings.Fields(mnemonic), " ")
+}
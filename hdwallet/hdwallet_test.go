@@ -0,0 +1,115 @@
+package hdwallet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DSiSc/crypto-suite/crypto"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		input  string
+		output DerivationPath
+	}{
+		{"m/44'/60'/0'/0", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000, 0}},
+		{"m/44'/60'/0'/0/12", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000, 0, 12}},
+		{" m / 44' / 60' ", DerivationPath{0x80000000 + 44, 0x80000000 + 60}},
+		{"m/2147483647'", DerivationPath{0xffffffff}},
+		{"", nil},
+		{"44'/60'", nil},
+		{"m", nil},
+		{"m/x", nil},
+		{"m/-1", nil},
+		{"m/2147483648'", nil},
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.input)
+		if test.output == nil {
+			assert.NotNil(t, err, test.input)
+			continue
+		}
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, test.output, path)
+	}
+	path, _ := ParseDerivationPath(DefaultBaseDerivationPath)
+	assert.Equal(t, DefaultBaseDerivationPath, path.String())
+	assert.Equal(t, DefaultBaseDerivationPath+"/3", path.Child(3).String())
+}
+
+func TestNewMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic(DefaultEntropyBits)
+	assert.Nil(t, err)
+	_, err = NewSeed(mnemonic, "")
+	assert.Nil(t, err)
+
+	_, err = NewSeed("abandon abandon", "")
+	assert.Equal(t, ErrInvalidMnemonic, err)
+}
+
+func TestDeriveKey(t *testing.T) {
+	seed, err := NewSeed(testMnemonic, "")
+	assert.Nil(t, err)
+	base, _ := ParseDerivationPath(DefaultBaseDerivationPath)
+	key, err := DeriveKey(seed, base.Child(0))
+	assert.Nil(t, err)
+	address := fmt.Sprintf("0x%x", crypto.PubkeyToAddress(key.PublicKey))
+	assert.Equal(t, "0x9858effd232b4033e47d90003d41ec34ecaeda94", address)
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdwallet-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewStore(dir, nil, 2, 1)
+	_, err = store.Open()
+	assert.Equal(t, ErrNoWallet, err)
+
+	w, err := store.Create(testMnemonic, "123", "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(w.Accounts))
+	assert.Equal(t, "0x9858effd232b4033e47d90003d41ec34ecaeda94", w.Accounts[0].Address)
+
+	_, err = store.Create(testMnemonic, "123", "", 1)
+	assert.Equal(t, ErrWalletExists, err)
+
+	_, err = store.Derive("wrong", 5)
+	assert.Equal(t, ErrDecrypt, err)
+
+	account, err := store.Derive("123", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/5", account.Path)
+
+	w, err = store.Open()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(w.Accounts))
+
+	mnemonic, err := store.Mnemonic("123")
+	assert.Nil(t, err)
+	assert.Equal(t, testMnemonic, mnemonic)
+}
+
+func TestStoreImportFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdwallet-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// a file in place of the keystore directory makes every import fail
+	blocker := filepath.Join(dir, "blocker")
+	assert.Nil(t, ioutil.WriteFile(blocker, nil, 0600))
+	ks := keystore.NewKeyStore(filepath.Join(blocker, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+
+	store := NewStore(dir, ks, 2, 1)
+	_, err = store.Create(testMnemonic, "123", "", 2)
+	assert.NotNil(t, err)
+	assert.False(t, store.Exists())
+	assert.Equal(t, 0, len(ks.Accounts()))
+}
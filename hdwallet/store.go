@@ -0,0 +1,360 @@
+package hdwallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/DSiSc/crypto-suite/crypto"
+	"github.com/DSiSc/wallet/accounts"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	"golang.org/x/crypto/scrypt"
+)
+
+// WalletFile is the name of the file, inside the store directory, holding the
+// encrypted mnemonic and the list of derived accounts.
+const WalletFile = "hdwallet.json"
+
+const (
+	scryptR     = 8
+	scryptDKLen = 32
+)
+
+var (
+	ErrWalletExists   = errors.New("hd wallet already exists")
+	ErrNoWallet       = errors.New("no hd wallet found, create or restore one first")
+	ErrDecrypt        = errors.New("could not decrypt hd wallet with given password")
+	ErrAccountCount   = errors.New("account count must be positive")
+	ErrAccountIndex   = errors.New("account index out of range")
+	errUnknownVersion = errors.New("unsupported hd wallet file version")
+)
+
+const walletVersion = 1
+
+// Account is a keystore account derived from the wallet seed.
+type Account struct {
+	Index   uint32 `json:"index"`
+	Path    string `json:"path"`
+	Address string `json:"address"`
+}
+
+// Wallet is the on-disk representation of a hierarchical deterministic wallet.
+// The mnemonic is only ever stored encrypted, derived keys are kept in the
+// keystore as regular encrypted key files.
+type Wallet struct {
+	Version  int        `json:"version"`
+	BasePath string     `json:"path"`
+	Accounts []Account  `json:"accounts"`
+	Crypto   cryptoJSON `json:"crypto"`
+}
+
+type cryptoJSON struct {
+	Cipher     string `json:"cipher"`
+	CipherText string `json:"ciphertext"`
+	Nonce      string `json:"nonce"`
+	KDF        string `json:"kdf"`
+	Salt       string `json:"salt"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+}
+
+// Store manages the hd wallet kept in a data directory and imports the keys it
+// derives into the given keystore.
+type Store struct {
+	dir      string
+	keystore *keystore.KeyStore
+	scryptN  int
+	scryptP  int
+}
+
+// NewStore creates a wallet store rooted at dir. scryptN and scryptP are the
+// key derivation parameters used to encrypt the mnemonic.
+func NewStore(dir string, ks *keystore.KeyStore, scryptN, scryptP int) *Store {
+	return &Store{
+		dir:      dir,
+		keystore: ks,
+		scryptN:  scryptN,
+		scryptP:  scryptP,
+	}
+}
+
+// Path returns the location of the wallet file.
+func (s *Store) Path() string {
+	return filepath.Join(s.dir, WalletFile)
+}
+
+// Exists reports whether a wallet has already been created in the store.
+func (s *Store) Exists() bool {
+	_, err := os.Stat(s.Path())
+	return err == nil
+}
+
+// Create stores a wallet for the given mnemonic, encrypted with password, and
+// derives the first count accounts below basePath into the keystore. An empty
+// basePath selects DefaultBaseDerivationPath.
+func (s *Store) Create(mnemonic, password, basePath string, count int) (*Wallet, error) {
+	if s.Exists() {
+		return nil, ErrWalletExists
+	}
+	if count <= 0 {
+		return nil, ErrAccountCount
+	}
+	if basePath == "" {
+		basePath = DefaultBaseDerivationPath
+	}
+	base, err := ParseDerivationPath(basePath)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic = normalize(mnemonic)
+	seed, err := NewSeed(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.encrypt([]byte(mnemonic), password)
+	if err != nil {
+		return nil, err
+	}
+	w := &Wallet{
+		Version:  walletVersion,
+		BasePath: base.String(),
+		Crypto:   sealed,
+	}
+	keys := make([]*ecdsa.PrivateKey, count)
+	for i := range keys {
+		if _, keys[i], err = s.derive(w, seed, uint32(i)); err != nil {
+			return nil, err
+		}
+	}
+	// import the keys only once the wallet is stored, and drop it again if
+	// they cannot be imported
+	if err := s.save(w); err != nil {
+		return nil, err
+	}
+	if err := s.importKeys(keys, password); err != nil {
+		if rmErr := os.Remove(s.Path()); rmErr != nil {
+			return nil, fmt.Errorf("%v, removing hd wallet %s: %v", err, s.Path(), rmErr)
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// Open loads the wallet from disk without decrypting it.
+func (s *Store) Open() (*Wallet, error) {
+	blob, err := ioutil.ReadFile(s.Path())
+	if os.IsNotExist(err) {
+		return nil, ErrNoWallet
+	}
+	if err != nil {
+		return nil, err
+	}
+	w := new(Wallet)
+	if err := json.Unmarshal(blob, w); err != nil {
+		return nil, fmt.Errorf("corrupt hd wallet %s: %v", s.Path(), err)
+	}
+	if w.Version != walletVersion {
+		return nil, errUnknownVersion
+	}
+	return w, nil
+}
+
+// Derive decrypts the wallet with password and imports the account at index
+// into the keystore, protected by the same password.
+func (s *Store) Derive(password string, index uint32) (Account, error) {
+	w, err := s.Open()
+	if err != nil {
+		return Account{}, err
+	}
+	plain, err := s.decrypt(w.Crypto, password)
+	if err != nil {
+		return Account{}, err
+	}
+	seed, err := NewSeed(string(plain), "")
+	if err != nil {
+		return Account{}, err
+	}
+	known := w.Accounts
+	account, key, err := s.derive(w, seed, index)
+	if err != nil {
+		return Account{}, err
+	}
+	if err := s.save(w); err != nil {
+		return Account{}, err
+	}
+	if err := s.importKeys([]*ecdsa.PrivateKey{key}, password); err != nil {
+		w.Accounts = known
+		if saveErr := s.save(w); saveErr != nil {
+			return Account{}, fmt.Errorf("%v, restoring hd wallet %s: %v", err, s.Path(), saveErr)
+		}
+		return Account{}, err
+	}
+	return account, nil
+}
+
+// Mnemonic decrypts and returns the mnemonic of the stored wallet.
+func (s *Store) Mnemonic(password string) (string, error) {
+	w, err := s.Open()
+	if err != nil {
+		return "", err
+	}
+	plain, err := s.decrypt(w.Crypto, password)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// derive computes the key at index and records its account in the wallet. The
+// key is not imported into the keystore, see importKeys.
+func (s *Store) derive(w *Wallet, seed []byte, index uint32) (Account, *ecdsa.PrivateKey, error) {
+	if index >= 1<<31 {
+		return Account{}, nil, ErrAccountIndex
+	}
+	base, err := ParseDerivationPath(w.BasePath)
+	if err != nil {
+		return Account{}, nil, err
+	}
+	path := base.Child(index)
+	key, err := DeriveKey(seed, path)
+	if err != nil {
+		return Account{}, nil, err
+	}
+	account := Account{
+		Index:   index,
+		Path:    path.String(),
+		Address: fmt.Sprintf("0x%x", crypto.PubkeyToAddress(key.PublicKey)),
+	}
+	for _, known := range w.Accounts {
+		if known.Index == index {
+			return known, key, nil
+		}
+	}
+	// copy, so that callers may restore the accounts they saw before
+	w.Accounts = append(append([]Account(nil), w.Accounts...), account)
+	sort.Slice(w.Accounts, func(i, j int) bool { return w.Accounts[i].Index < w.Accounts[j].Index })
+	return account, key, nil
+}
+
+// importKeys imports the keys not yet present into the keystore, protected by
+// password. If an import fails the keys imported so far are deleted again, so
+// that no account is left behind without its wallet entry.
+func (s *Store) importKeys(keys []*ecdsa.PrivateKey, password string) error {
+	if s.keystore == nil {
+		return nil
+	}
+	var imported []accounts.Account
+	for _, key := range keys {
+		if s.keystore.HasAddress(crypto.PubkeyToAddress(key.PublicKey)) {
+			continue
+		}
+		account, err := s.keystore.ImportECDSA(key, password)
+		if err != nil {
+			for _, a := range imported {
+				s.keystore.Delete(a, password)
+			}
+			return err
+		}
+		imported = append(imported, account)
+	}
+	return nil
+}
+
+func (s *Store) save(w *Wallet) error {
+	blob, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(s.dir, "."+WalletFile+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), s.Path())
+}
+
+func (s *Store) encrypt(data []byte, password string) (cryptoJSON, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return cryptoJSON{}, err
+	}
+	key, err := scrypt.Key([]byte(password), salt, s.scryptN, scryptR, s.scryptP, scryptDKLen)
+	if err != nil {
+		return cryptoJSON{}, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return cryptoJSON{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return cryptoJSON{}, err
+	}
+	return cryptoJSON{
+		Cipher:     "aes-256-gcm",
+		CipherText: hex.EncodeToString(gcm.Seal(nil, nonce, data, nil)),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        "scrypt",
+		Salt:       hex.EncodeToString(salt),
+		N:          s.scryptN,
+		R:          scryptR,
+		P:          s.scryptP,
+	}, nil
+}
+
+func (s *Store) decrypt(c cryptoJSON, password string) ([]byte, error) {
+	if c.Cipher != "aes-256-gcm" || c.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported hd wallet cipher %s/%s", c.KDF, c.Cipher)
+	}
+	salt, err := hex.DecodeString(c.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(c.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(password), salt, c.N, c.R, c.P, scryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
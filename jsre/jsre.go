@@ -50,6 +50,20 @@ type JSRE struct {
 	evalQueue     chan *evalReq
 	stopEventLoop chan bool
 	closed        chan struct{}
+
+	// written by the event loop only, read after a request or the loop is done
+	exit    *ExitError // set once a script called Exit
+	failure error      // first error thrown by a timer callback
+}
+
+// ExitError is returned by Exec and Wait once a script called the function
+// bound to Exit. It ends the event loop.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
 }
 
 // jsTimer is a single timer instance with a callback function
@@ -188,9 +202,15 @@ loop:
 				arguments = make([]interface{}, 1)
 			}
 			arguments[0] = timer.call.ArgumentList[0]
-			_, err := vm.Call(`Function.call.call`, nil, arguments...)
+			var err error
+			if re.guard(func() { _, err = vm.Call(`Function.call.call`, nil, arguments...) }) {
+				break loop
+			}
 			if err != nil {
 				fmt.Println("js error:", err, arguments)
+				if re.failure == nil {
+					re.failure = err
+				}
 			}
 
 			_, inreg := registry[timer] // when clearInterval is called from within the callback don't reset it
@@ -204,9 +224,9 @@ loop:
 			}
 		case req := <-re.evalQueue:
 			// run the code, send the result back
-			req.fn(vm)
+			exited := re.guard(func() { req.fn(vm) })
 			close(req.done)
-			if waitForCallbacks && (len(registry) == 0) {
+			if exited || (waitForCallbacks && (len(registry) == 0)) {
 				break loop
 			}
 		case waitForCallbacks = <-re.stopEventLoop:
@@ -222,6 +242,41 @@ loop:
 	}
 }
 
+// guard runs fn on the event loop, recording the *ExitError of a script which
+// called Exit. It reports whether the script exited.
+func (re *JSRE) guard(fn func()) (exited bool) {
+	defer func() {
+		if caught := recover(); caught != nil {
+			exit, ok := caught.(*ExitError)
+			if !ok {
+				panic(caught)
+			}
+			re.exit, exited = exit, true
+		}
+	}()
+	fn()
+	return false
+}
+
+// Exit is a JavaScript function ending the script with the exit code given as
+// argument, 0 if none. Exec and Wait return the *ExitError of the code and the
+// event loop stops. Bind it to make it available, e.g. as process.exit.
+func (re *JSRE) Exit(call otto.FunctionCall) otto.Value {
+	code, _ := call.Argument(0).ToInteger()
+	panic(&ExitError{Code: int(code)})
+}
+
+// Wait waits for all timers to fire and stops the event loop. It returns the
+// *ExitError of a script which called Exit, otherwise the first error thrown
+// by a timer callback.
+func (re *JSRE) Wait() error {
+	re.Stop(true)
+	if re.exit != nil {
+		return re.exit
+	}
+	return re.failure
+}
+
 // Do executes the given function on the JS event loop.
 func (re *JSRE) Do(fn func(*otto.Otto)) {
 	done := make(chan bool)
@@ -254,6 +309,9 @@ func (re *JSRE) Exec(file string) error {
 		}
 		_, err = vm.Run(script)
 	})
+	if re.exit != nil {
+		return re.exit
+	}
 	return err
 }
 
@@ -303,14 +361,15 @@ func (re *JSRE) loadScript(call otto.FunctionCall) otto.Value {
 	return otto.TrueValue()
 }
 
-// Evaluate executes code and pretty prints the result to the specified output
-// stream.
+// Evaluate executes code and pretty prints the result or the error it threw to
+// the specified output stream. It returns the error.
 func (re *JSRE) Evaluate(code string, w io.Writer) error {
 	var fail error
 
 	re.Do(func(vm *otto.Otto) {
 		val, err := vm.Run(code)
 		if err != nil {
+			fail = err
 			prettyError(vm, err, w)
 		} else {
 			prettyPrint(vm, val, w)
@@ -320,6 +379,39 @@ func (re *JSRE) Evaluate(code string, w io.Writer) error {
 	return fail
 }
 
+// EvaluateJSON executes code and writes the result to w as one line of JSON.
+// Values without JSON representation, like undefined and functions, are
+// written as null. It returns the error thrown by code.
+func (re *JSRE) EvaluateJSON(code string, w io.Writer) error {
+	var fail error
+
+	re.Do(func(vm *otto.Otto) {
+		val, err := vm.Run(code)
+		if err == nil {
+			val, err = ToJSON(vm, val)
+		}
+		if err != nil {
+			fail = err
+			return
+		}
+		fmt.Fprintln(w, val.String())
+	})
+	return fail
+}
+
+// ToJSON returns the JSON encoding of v as produced by JSON.stringify, null
+// for values without JSON representation. It must be called on the event loop.
+func ToJSON(vm *otto.Otto, v otto.Value) (otto.Value, error) {
+	out, err := vm.Call("JSON.stringify", nil, v)
+	if err != nil {
+		return otto.Value{}, err
+	}
+	if out.IsUndefined() {
+		return vm.ToValue("null")
+	}
+	return out, nil
+}
+
 // Compile compiles and then runs a piece of JS code.
 func (re *JSRE) Compile(filename string, src interface{}) (err error) {
 	re.Do(func(vm *otto.Otto) { _, err = compileAndRun(vm, filename, src) })
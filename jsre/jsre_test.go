@@ -17,6 +17,7 @@
 package jsre
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path"
@@ -135,3 +136,67 @@ func TestLoadScript(t *testing.T) {
 	}
 	jsre.Stop(false)
 }
+
+func TestExit(t *testing.T) {
+	jsre, dir := newWithTestJS(t, `msg = "before"; try { exit(3); } catch (e) {} msg = "after";`)
+	defer os.RemoveAll(dir)
+	jsre.Set("exit", jsre.Exit)
+
+	err := jsre.Exec("test.js")
+	if exit, ok := err.(*ExitError); !ok || exit.Code != 3 {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	// the event loop is stopped, Wait returns right away
+	if _, ok := jsre.Wait().(*ExitError); !ok {
+		t.Error("expected the exit error from Wait")
+	}
+}
+
+func TestWait(t *testing.T) {
+	jsre, dir := newWithTestJS(t, `setTimeout(function(){ msg = "testMsg"; }, 1); setTimeout(function(){ throw new Error("boom"); }, 10);`)
+	defer os.RemoveAll(dir)
+
+	if err := jsre.Exec("test.js"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := jsre.Wait(); err == nil {
+		t.Error("expected the error of the timer callback")
+	}
+
+	jsre, dir = newWithTestJS(t, `setTimeout(function(){ exit(); }, 1); setInterval(function(){}, 1);`)
+	defer os.RemoveAll(dir)
+	jsre.Set("exit", jsre.Exit)
+	if err := jsre.Exec("test.js"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err, ok := jsre.Wait().(*ExitError); !ok || err.Code != 0 {
+		t.Errorf("expected exit status 0, got %v", err)
+	}
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	jsre := New("", os.Stdout)
+	defer jsre.Stop(false)
+
+	tests := []struct {
+		code, want string
+	}{
+		{`({a: [1, "two"], b: null})`, `{"a":[1,"two"],"b":null}` + "\n"},
+		{`"text"`, `"text"` + "\n"},
+		{`undefined`, "null\n"},
+		{`(function() {})`, "null\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := jsre.EvaluateJSON(test.code, &out); err != nil {
+			t.Errorf("%s: expected no error, got %v", test.code, err)
+		}
+		if out.String() != test.want {
+			t.Errorf("%s: expected %q, got %q", test.code, test.want, out.String())
+		}
+	}
+	var out bytes.Buffer
+	if err := jsre.EvaluateJSON(`throw new Error("boom")`, &out); err == nil || out.Len() != 0 {
+		t.Errorf("expected the thrown error and no output, got %v and %q", err, out.String())
+	}
+}
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// record is a log entry ready to be formatted.
+type record struct {
+	time  time.Time
+	level Level
+	msg   string
+	ctx   []interface{}
+}
+
+const (
+	textTimeFormat = "01-02|15:04:05.000"
+	msgJust        = 40 // column of the first key in text records
+)
+
+// formatText formats r for humans, e.g.
+//
+//	INFO [10-18|15:04:05.000] Gateway switched                         previous=http://127.0.0.1:47768
+func formatText(r *record) []byte {
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "%-5s[%s] %s", strings.ToUpper(r.level.String()), r.time.Format(textTimeFormat), r.msg)
+	if len(r.ctx) > 0 && len(r.msg) < msgJust {
+		b.Write(bytes.Repeat([]byte{' '}, msgJust-len(r.msg)))
+	}
+	for i := 0; i < len(r.ctx); i += 2 {
+		fmt.Fprintf(b, " %s=%s", r.ctx[i], formatTextValue(r.ctx[i+1]))
+	}
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+func formatTextValue(v interface{}) string {
+	s := formatValue(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// formatJSON formats r as one JSON object per line.
+func formatJSON(r *record) []byte {
+	props := map[string]interface{}{
+		"t":   r.time.Format(time.RFC3339Nano),
+		"lvl": r.level.String(),
+		"msg": r.msg,
+	}
+	for i := 0; i < len(r.ctx); i += 2 {
+		switch v := r.ctx[i+1].(type) {
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, nil:
+			props[r.ctx[i].(string)] = v
+		default:
+			props[r.ctx[i].(string)] = formatValue(v)
+		}
+	}
+	blob, err := json.Marshal(props)
+	if err != nil {
+		blob, _ = json.Marshal(map[string]string{"LOG_ERROR": err.Error(), "msg": r.msg})
+	}
+	return append(blob, '\n')
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
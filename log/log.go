@@ -0,0 +1,205 @@
+// Package log is the structured logger of astraia. Records carry a message and
+// alternating key/value pairs:
+//
+//	log.Info("Gateway switched", "previous", previous, "current", current)
+//
+// They are written as text or JSON to standard error or to a size rotated
+// file, with the values of secret keys such as passwords and values looking
+// like raw private keys redacted.
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level is the severity of a record, higher levels are more verbose. The
+// numeric values are the ones accepted by --verbosity and debug.verbosity.
+type Level int32
+
+const (
+	LvlCrit Level = iota
+	LvlError
+	LvlWarn
+	LvlInfo
+	LvlDebug
+	LvlTrace
+)
+
+var levelNames = []string{"crit", "error", "warn", "info", "debug", "trace"}
+
+func (l Level) String() string {
+	if l < LvlCrit || l > LvlTrace {
+		return strconv.Itoa(int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name or its number.
+func ParseLevel(s string) (Level, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < int(LvlCrit) || n > int(LvlTrace) {
+			return 0, fmt.Errorf("verbosity %d out of range %d-%d", n, LvlCrit, LvlTrace)
+		}
+		return Level(n), nil
+	}
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, expected one of %s or 0-5", s, strings.Join(levelNames, ", "))
+}
+
+// Formats of the records.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Logger writes records at or below its level to its output.
+type Logger struct {
+	level int32 // Level, accessed atomically
+
+	mu     sync.Mutex // protects the fields below and serializes writes
+	out    io.Writer
+	format string
+}
+
+// New creates a logger writing records up to level to out in the given format.
+func New(out io.Writer, level Level, format string) *Logger {
+	return &Logger{level: int32(level), out: out, format: format}
+}
+
+var root = New(os.Stderr, LvlInfo, FormatText)
+
+// Root returns the logger used by the package level functions.
+func Root() *Logger {
+	return root
+}
+
+// SetLevel changes the most verbose level written by l.
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+// Level returns the most verbose level written by l.
+func (l *Logger) Level() Level {
+	return Level(atomic.LoadInt32(&l.level))
+}
+
+// SetOutput replaces the output and format of l.
+func (l *Logger) SetOutput(out io.Writer, format string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out, l.format = out, format
+}
+
+func (l *Logger) Trace(msg string, ctx ...interface{}) { l.write(LvlTrace, msg, ctx) }
+func (l *Logger) Debug(msg string, ctx ...interface{}) { l.write(LvlDebug, msg, ctx) }
+func (l *Logger) Info(msg string, ctx ...interface{})  { l.write(LvlInfo, msg, ctx) }
+func (l *Logger) Warn(msg string, ctx ...interface{})  { l.write(LvlWarn, msg, ctx) }
+func (l *Logger) Error(msg string, ctx ...interface{}) { l.write(LvlError, msg, ctx) }
+func (l *Logger) Crit(msg string, ctx ...interface{})  { l.write(LvlCrit, msg, ctx) }
+
+func (l *Logger) write(level Level, msg string, ctx []interface{}) {
+	if level > l.Level() {
+		return
+	}
+	r := &record{time: time.Now(), level: level, msg: msg, ctx: redact(normalize(ctx))}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.format == FormatJSON {
+		l.out.Write(formatJSON(r))
+	} else {
+		l.out.Write(formatText(r))
+	}
+}
+
+// normalize makes ctx a list of string keys and values, reporting odd ones.
+func normalize(ctx []interface{}) []interface{} {
+	if len(ctx)%2 != 0 {
+		ctx = append(ctx, nil, "LOG_ERROR", "odd number of context values")
+	}
+	for i := 0; i < len(ctx); i += 2 {
+		if _, ok := ctx[i].(string); !ok {
+			ctx[i] = fmt.Sprint(ctx[i])
+		}
+	}
+	return ctx
+}
+
+func Trace(msg string, ctx ...interface{}) { root.write(LvlTrace, msg, ctx) }
+func Debug(msg string, ctx ...interface{}) { root.write(LvlDebug, msg, ctx) }
+func Info(msg string, ctx ...interface{})  { root.write(LvlInfo, msg, ctx) }
+func Warn(msg string, ctx ...interface{})  { root.write(LvlWarn, msg, ctx) }
+func Error(msg string, ctx ...interface{}) { root.write(LvlError, msg, ctx) }
+func Crit(msg string, ctx ...interface{})  { root.write(LvlCrit, msg, ctx) }
+
+// SetVerbosity changes the most verbose level written by the root logger.
+func SetVerbosity(level Level) {
+	root.SetLevel(level)
+}
+
+// Verbosity returns the most verbose level written by the root logger.
+func Verbosity() Level {
+	return root.Level()
+}
+
+// Options configures the root logger.
+type Options struct {
+	Level      string // level name or number
+	Format     string // FormatText or FormatJSON
+	File       string // log file, standard error if empty
+	MaxSize    int    // size in megabytes at which the file is rotated, 0 disables rotation
+	MaxBackups int    // rotated files kept
+}
+
+// Validate checks the level and format of the options.
+func (o *Options) Validate() error {
+	if _, err := ParseLevel(o.Level); err != nil {
+		return err
+	}
+	if o.Format != FormatText && o.Format != FormatJSON {
+		return fmt.Errorf("unknown log format %q, expected %s or %s", o.Format, FormatText, FormatJSON)
+	}
+	if o.MaxSize < 0 || o.MaxBackups < 0 {
+		return fmt.Errorf("log rotation sizes must not be negative")
+	}
+	return nil
+}
+
+var rootFile io.Closer // file opened by the last Setup
+
+// Setup configures the root logger, closing the file of a previous setup.
+func Setup(o Options) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	level, _ := ParseLevel(o.Level)
+
+	var out io.Writer = os.Stderr
+	var file io.Closer
+	if o.File != "" {
+		f, err := openRotating(o.File, int64(o.MaxSize)<<20, o.MaxBackups)
+		if err != nil {
+			return err
+		}
+		out, file = f, f
+	}
+	root.SetOutput(out, o.Format)
+	root.SetLevel(level)
+
+	if rootFile != nil {
+		rootFile.Close()
+	}
+	rootFile = file
+	return nil
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+func TestParseLevel(t *testing.T) {
+	assert := assert.New(t)
+	for s, want := range map[string]Level{"0": LvlCrit, "3": LvlInfo, "5": LvlTrace, "debug": LvlDebug, "WARN": LvlWarn} {
+		level, err := ParseLevel(s)
+		assert.Nil(err, s)
+		assert.Equal(want, level, s)
+	}
+	for _, s := range []string{"6", "-1", "verbose", ""} {
+		_, err := ParseLevel(s)
+		assert.NotNil(err, s)
+	}
+}
+
+func TestText(t *testing.T) {
+	assert := assert.New(t)
+	out := new(bytes.Buffer)
+	l := New(out, LvlInfo, FormatText)
+
+	l.Debug("hidden")
+	l.Info("Gateway switched", "previous", "http://127.0.0.1:47768", "err", errors.New("two words"))
+	l.SetLevel(LvlDebug)
+	l.Debug("shown", "odd")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(lines, 2)
+	assert.True(strings.HasPrefix(lines[0], "INFO ["))
+	assert.True(strings.HasSuffix(lines[0], `previous=http://127.0.0.1:47768 err="two words"`))
+	assert.True(strings.HasPrefix(lines[1], "DEBUG["))
+	assert.True(strings.HasSuffix(lines[1], `odd=nil LOG_ERROR="odd number of context values"`))
+}
+
+func TestJSON(t *testing.T) {
+	assert := assert.New(t)
+	out := new(bytes.Buffer)
+	l := New(out, LvlInfo, FormatJSON)
+
+	l.Warn("Config reload failed", "attempt", 2, "err", errors.New("invalid"))
+	var props map[string]interface{}
+	assert.Nil(json.Unmarshal(out.Bytes(), &props))
+	assert.Equal("warn", props["lvl"])
+	assert.Equal("Config reload failed", props["msg"])
+	assert.Equal(float64(2), props["attempt"])
+	assert.Equal("invalid", props["err"])
+}
+
+func TestRedact(t *testing.T) {
+	assert := assert.New(t)
+	out := new(bytes.Buffer)
+	l := New(out, LvlInfo, FormatText)
+
+	l.Info("Signing", "password", "123", "Passphrase", "abc", "mnemonic", "abandon", "key", "0x"+testKey,
+		"err", "invalid key "+testKey+" given", "hash", "0x"+testKey)
+	line := out.String()
+	assert.NotContains(line, "123")
+	assert.NotContains(line, "abc")
+	assert.NotContains(line, "abandon")
+	assert.Contains(line, "key=<redacted>")
+	assert.Contains(line, `err="invalid key <redacted> given"`)
+	assert.Contains(line, "hash=0x"+testKey)
+	assert.Equal(1, strings.Count(line, testKey))
+}
+
+func TestSetupRotation(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "log")
+	defer os.RemoveAll(dir)
+	defer Setup(Options{Level: "info", Format: FormatText})
+
+	assert.NotNil(Setup(Options{Level: "loud", Format: FormatText}))
+	assert.NotNil(Setup(Options{Level: "info", Format: "xml"}))
+
+	path := filepath.Join(dir, "astraia.log")
+	assert.Nil(Setup(Options{Level: "3", Format: FormatJSON, File: path, MaxBackups: 2}))
+	file := rootFile.(*rotatingFile)
+	file.maxSize = 200 // bytes, to rotate within the test
+
+	for i := 0; i < 10; i++ {
+		Info("A record long enough to fill the log file", "i", i)
+	}
+	Debug("hidden")
+	_, err := os.Stat(path + ".1")
+	assert.Nil(err)
+	_, err = os.Stat(path + ".2")
+	assert.Nil(err)
+	_, err = os.Stat(path + ".3")
+	assert.True(os.IsNotExist(err))
+
+	current, _ := ioutil.ReadFile(path)
+	assert.Contains(string(current), `"i":9`)
+	assert.NotContains(string(current), "hidden")
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"regexp"
+)
+
+// Redacted replaces the value of secret keys and values which look like raw
+// private keys.
+const Redacted = "<redacted>"
+
+var (
+	secretKeyRegexp   = regexp.MustCompile(`(?i)pass(word|phrase)?|secret|token|private|priv.?key|mnemonic|seed|^pin$`)
+	privateKeyRegexp  = regexp.MustCompile(`(?i)^(0x)?[0-9a-f]{64}$`)
+	embeddedKeyRegexp = regexp.MustCompile(`(?i)\b(0x)?[0-9a-f]{64}\b`)
+)
+
+// redact replaces the secret values of ctx, a normalized list of key/value
+// pairs. Transaction hashes are 32 bytes like private keys, so values of keys
+// named hash are kept.
+func redact(ctx []interface{}) []interface{} {
+	for i := 0; i < len(ctx); i += 2 {
+		key := ctx[i].(string)
+		if secretKeyRegexp.MatchString(key) {
+			ctx[i+1] = Redacted
+			continue
+		}
+		if key == "hash" || key == "txhash" {
+			continue
+		}
+		ctx[i+1] = RedactValue(ctx[i+1])
+	}
+	return ctx
+}
+
+// RedactValue replaces raw private keys in string values.
+func RedactValue(v interface{}) interface{} {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return v
+	}
+	if privateKeyRegexp.MatchString(s) {
+		return Redacted
+	}
+	return embeddedKeyRegexp.ReplaceAllString(s, Redacted)
+}
@@ -0,0 +1,62 @@
+package rules
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditEntry is a single signing decision as written to the audit log.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Request  *Request  `json:"request"`
+	Rules    Decision  `json:"rules"`    // decision of the rules
+	Decision Decision  `json:"decision"` // final decision, after prompting the user
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// AuditLog appends signing decisions to a file, one JSON object per line.
+// Passwords never reach the log, requests don't carry them.
+type AuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewAuditLog opens (creating if needed) the audit log at path for appending.
+func NewAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{file: file}, nil
+}
+
+// Log records a decision and flushes it to disk.
+func (l *AuditLog) Log(req *Request, rules, decision Decision, reason string) error {
+	blob, err := json.Marshal(&AuditEntry{
+		Time:     time.Now().UTC(),
+		Request:  req,
+		Rules:    rules,
+		Decision: decision,
+		Reason:   reason,
+	})
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(blob, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// Close closes the underlying file.
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
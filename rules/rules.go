@@ -0,0 +1,212 @@
+// Package rules implements the approval of signing requests by user supplied
+// JavaScript rules, evaluated in a JavaScript runtime dedicated to them.
+//
+// A rules file defines a function ApproveTx, called with the decoded request:
+//
+//	function ApproveTx(req) {
+//		if (req.to == "0x47c5e40890bce4a473a49d7501808b9633f29782") {
+//			return "Approve";
+//		}
+//		if (new BigNumber(req.value).lessThan(1000)) {
+//			return "Prompt";
+//		}
+//		return "Reject";
+//	}
+//
+// Any result other than "Approve", "Reject" or "Prompt", a missing ApproveTx
+// function and exceptions thrown by the rules reject the request.
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+
+	"github.com/DSiSc/astraia/jsre"
+	ctypes "github.com/DSiSc/craft/types"
+	"github.com/robertkrimen/otto"
+)
+
+// Decision is the outcome of evaluating a signing request.
+type Decision string
+
+const (
+	Approve Decision = "Approve"
+	Reject  Decision = "Reject"
+	Prompt  Decision = "Prompt"
+)
+
+// ErrRejected is returned for signing requests that were not approved.
+var ErrRejected = errors.New("signing request rejected")
+
+// ConfirmFunc asks the user to confirm a signing request the rules left to them.
+type ConfirmFunc func(prompt string) (bool, error)
+
+// CrossChain describes the cross-chain part of a signing request.
+type CrossChain struct {
+	Target    string `json:"target"`          // recipient (or querying sender) on the other chain
+	ChainFlag string `json:"chainFlag"`       // identifier of the other chain
+	Inner     bool   `json:"inner,omitempty"` // the transaction run on the other chain, embedded in the outer one
+}
+
+// Request is the decoded signing request handed to the rules.
+type Request struct {
+	Method     string      `json:"method"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Value      string      `json:"value"`    // decimal amount
+	GasPrice   string      `json:"gasPrice"` // decimal price
+	Gas        uint64      `json:"gas"`
+	Nonce      uint64      `json:"nonce"`
+	Input      string      `json:"input"` // hex encoded payload
+	CrossChain *CrossChain `json:"crossChain,omitempty"`
+	Network    string      `json:"network,omitempty"` // network in use, if any
+	ChainID    uint64      `json:"chainId,omitempty"`
+}
+
+// NewRequest decodes tx into a request for the given signing method. cross is
+// nil for requests which stay on the current chain.
+func NewRequest(method string, tx *ctypes.Transaction, cross *CrossChain) *Request {
+	req := &Request{
+		Method:     method,
+		Value:      "0",
+		GasPrice:   "0",
+		Gas:        tx.Data.GasLimit,
+		Nonce:      tx.Data.AccountNonce,
+		Input:      fmt.Sprintf("0x%x", tx.Data.Payload),
+		CrossChain: cross,
+	}
+	if tx.Data.From != nil {
+		req.From = fmt.Sprintf("0x%x", *tx.Data.From)
+	}
+	if tx.Data.Recipient != nil {
+		req.To = fmt.Sprintf("0x%x", *tx.Data.Recipient)
+	}
+	if tx.Data.Amount != nil {
+		req.Value = tx.Data.Amount.String()
+	}
+	if tx.Data.Price != nil {
+		req.GasPrice = tx.Data.Price.String()
+	}
+	return req
+}
+
+// String returns the one line summary shown when prompting the user.
+func (req *Request) String() string {
+	value, _ := new(big.Int).SetString(req.Value, 10)
+	s := fmt.Sprintf("%s from %s to %s, value %v, nonce %d", req.Method, req.From, req.To, value, req.Nonce)
+	if req.CrossChain != nil {
+		s += fmt.Sprintf(", cross-chain %s on %s", req.CrossChain.Target, req.CrossChain.ChainFlag)
+		if req.CrossChain.Inner {
+			s += " (inner transaction)"
+		}
+	}
+	return s
+}
+
+// Engine evaluates signing requests against a rules file and records every
+// decision in the audit log.
+type Engine struct {
+	jsre    *jsre.JSRE
+	audit   *AuditLog
+	confirm ConfirmFunc
+
+	mu sync.Mutex // serializes prompts and audit entries
+}
+
+// NewEngine loads the rules file into a new JavaScript runtime. audit may be
+// nil to disable auditing, confirm may be nil if there is no user to prompt, in
+// which case requests the rules leave to the user are rejected.
+func NewEngine(rulesFile string, audit *AuditLog, confirm ConfirmFunc) (*Engine, error) {
+	re := jsre.New(filepath.Dir(rulesFile), ioutil.Discard)
+	if err := re.Compile("bignumber.js", jsre.BignumberJs); err != nil {
+		re.Stop(false)
+		return nil, fmt.Errorf("bignumber.js: %v", err)
+	}
+	if err := re.Exec(rulesFile); err != nil {
+		re.Stop(false)
+		return nil, fmt.Errorf("%s: %v", rulesFile, err)
+	}
+	return &Engine{jsre: re, audit: audit, confirm: confirm}, nil
+}
+
+// Evaluate runs the rules on req, returning their decision and, for rejected
+// requests, the reason.
+func (e *Engine) Evaluate(req *Request) (Decision, string) {
+	blob, err := json.Marshal(req)
+	if err != nil {
+		return Reject, err.Error()
+	}
+	var (
+		result otto.Value
+		fail   error
+	)
+	e.jsre.Do(func(vm *otto.Otto) {
+		if fn, _ := vm.Get("ApproveTx"); !fn.IsFunction() {
+			fail = errors.New("rules define no ApproveTx function")
+			return
+		}
+		var arg otto.Value
+		if arg, fail = vm.Call("JSON.parse", nil, string(blob)); fail != nil {
+			return
+		}
+		result, fail = vm.Call("ApproveTx", nil, arg)
+	})
+	if fail != nil {
+		return Reject, fail.Error()
+	}
+	switch decision := Decision(result.String()); decision {
+	case Approve, Prompt:
+		return decision, ""
+	case Reject:
+		return Reject, "rejected by rules"
+	default:
+		return Reject, fmt.Sprintf("rules returned unknown decision %q", result.String())
+	}
+}
+
+// Approve evaluates req, prompts the user if the rules ask for it, and logs the
+// final decision. It returns nil only for approved requests.
+func (e *Engine) Approve(req *Request) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	decision, reason := e.Evaluate(req)
+	ruled := decision
+	if decision == Prompt {
+		decision, reason = Reject, "no user to confirm"
+		if e.confirm != nil {
+			ok, err := e.confirm(fmt.Sprintf("Approve %s?", req))
+			switch {
+			case err != nil:
+				reason = err.Error()
+			case ok:
+				decision, reason = Approve, "confirmed by user"
+			default:
+				reason = "declined by user"
+			}
+		}
+	}
+	if e.audit != nil {
+		if err := e.audit.Log(req, ruled, decision, reason); err != nil {
+			// an unaudited signature is not acceptable
+			return fmt.Errorf("audit log: %v", err)
+		}
+	}
+	if decision != Approve {
+		return fmt.Errorf("%v: %s", ErrRejected, reason)
+	}
+	return nil
+}
+
+// Stop terminates the rules runtime and closes the audit log.
+func (e *Engine) Stop() {
+	e.jsre.Stop(false)
+	if e.audit != nil {
+		e.audit.Close()
+	}
+}
@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRules = `
+function ApproveTx(req) {
+	if (req.crossChain) {
+		return req.crossChain.chainFlag == "chainB" ? "Approve" : "Reject";
+	}
+	if (req.to == "0x47c5e40890bce4a473a49d7501808b9633f29782") {
+		return "Approve";
+	}
+	if (new BigNumber(req.value).lessThan(1000)) {
+		return "Prompt";
+	}
+	if (req.value == "1234") {
+		throw new Error("unlucky value");
+	}
+	return "Reject";
+}
+`
+
+func newTestEngine(t *testing.T, rules string, confirm ConfirmFunc) (*Engine, string) {
+	dir, err := ioutil.TempDir("", "rules-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "rules.js")
+	if err := ioutil.WriteFile(file, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	audit, err := NewAuditLog(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewEngine(file, audit, confirm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine, dir
+}
+
+func TestEvaluate(t *testing.T) {
+	engine, dir := newTestEngine(t, testRules, nil)
+	defer os.RemoveAll(dir)
+	defer engine.Stop()
+
+	tests := []struct {
+		req      Request
+		decision Decision
+	}{
+		{Request{To: "0x47c5e40890bce4a473a49d7501808b9633f29782", Value: "5000"}, Approve},
+		{Request{To: "0x9f026b8fec907c3747ecd8f167e41e724def98b1", Value: "999"}, Prompt},
+		{Request{To: "0x9f026b8fec907c3747ecd8f167e41e724def98b1", Value: "5000"}, Reject},
+		{Request{To: "0x9f026b8fec907c3747ecd8f167e41e724def98b1", Value: "1234"}, Reject},
+		{Request{Value: "5000", CrossChain: &CrossChain{ChainFlag: "chainB"}}, Approve},
+		{Request{Value: "5000", CrossChain: &CrossChain{ChainFlag: "chainC"}}, Reject},
+	}
+	for i, test := range tests {
+		decision, _ := engine.Evaluate(&test.req)
+		assert.Equal(t, test.decision, decision, "test %d", i)
+	}
+}
+
+func TestEvaluateWithoutApproveTx(t *testing.T) {
+	engine, dir := newTestEngine(t, `var x = 1;`, nil)
+	defer os.RemoveAll(dir)
+	defer engine.Stop()
+
+	decision, reason := engine.Evaluate(&Request{Value: "0"})
+	assert.Equal(t, Reject, decision)
+	assert.Equal(t, "rules define no ApproveTx function", reason)
+}
+
+func TestApprove(t *testing.T) {
+	var (
+		prompts int
+		answer  bool
+		fail    error
+	)
+	confirm := func(prompt string) (bool, error) {
+		prompts++
+		return answer, fail
+	}
+	engine, dir := newTestEngine(t, testRules, confirm)
+	defer os.RemoveAll(dir)
+
+	small := &Request{To: "0x9f026b8fec907c3747ecd8f167e41e724def98b1", Value: "10"}
+
+	assert.Nil(t, engine.Approve(&Request{To: "0x47c5e40890bce4a473a49d7501808b9633f29782", Value: "5000"}))
+	assert.Equal(t, 0, prompts)
+
+	answer = true
+	assert.Nil(t, engine.Approve(small))
+	answer = false
+	assert.NotNil(t, engine.Approve(small))
+	fail = errors.New("no terminal")
+	assert.NotNil(t, engine.Approve(small))
+	assert.Equal(t, 3, prompts)
+	engine.Stop()
+
+	// every decision must have been audited
+	file, err := os.Open(filepath.Join(dir, "audit.log"))
+	assert.Nil(t, err)
+	defer file.Close()
+
+	var decisions []Decision
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &entry))
+		decisions = append(decisions, entry.Decision)
+	}
+	assert.Equal(t, []Decision{Approve, Approve, Reject, Reject}, decisions)
+}
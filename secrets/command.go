@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DefaultKeyringService is the keyring service of sources given as keyring:.
+const DefaultKeyringService = "astraia"
+
+// EnvAccount is set to the account in the environment of password commands.
+const EnvAccount = "ASTRAIA_ACCOUNT"
+
+// command is a password source printing the password on its standard output.
+type command struct {
+	name string
+	args []string
+	env  bool // pass the account in EnvAccount
+}
+
+// Command runs name with args and reads the password from the first line of
+// its standard output. The account is passed in $ASTRAIA_ACCOUNT.
+func Command(name string, args ...string) Provider {
+	return &command{name: name, args: args, env: true}
+}
+
+func (c *command) Password(account string) (string, error) {
+	cmd := exec.Command(c.name, c.args...)
+	if c.env {
+		cmd.Env = append(os.Environ(), EnvAccount+"="+account)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("password command %s failed: %v: %s", c.name, err, msg)
+		}
+		return "", fmt.Errorf("password command %s failed: %v", c.name, err)
+	}
+	return firstLine(stdout.Bytes()), nil
+}
+
+// Keyring reads the password of an account from the keyring of the operating
+// system, stored under service with the address as account name: the Secret
+// Service through secret-tool on Linux, the login keychain through security
+// on macOS.
+func Keyring(service string) Provider {
+	return ProviderFunc(func(account string) (string, error) {
+		var c *command
+		switch runtime.GOOS {
+		case "linux", "freebsd", "openbsd":
+			c = &command{name: "secret-tool", args: []string{"lookup", "service", service, "account", account}}
+		case "darwin":
+			c = &command{name: "security", args: []string{"find-generic-password", "-s", service, "-a", account, "-w"}}
+		default:
+			return "", errors.New("no keyring support on " + runtime.GOOS)
+		}
+		password, err := c.Password(account)
+		if err != nil {
+			return "", fmt.Errorf("keyring %s: %v", service, err)
+		}
+		return password, nil
+	})
+}
@@ -0,0 +1,194 @@
+// Package secrets resolves the passwords of accounts from configurable
+// sources, so that scripts and unattended runs need not embed them. A source
+// is given as scheme:argument:
+//
+//	file:/run/secrets/wallet   first line of a file
+//	env:WALLET_PASSWORD        environment variable
+//	keyring:astraia            OS keyring entry of the account in a service
+//	cmd:pass show astraia      standard output of a command, ASTRAIA_ACCOUNT
+//	                           holds the account
+//
+// Further schemes can be added with Register.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned for accounts without password source.
+var ErrNotFound = errors.New("no password source configured")
+
+// Provider returns the password of an account, given as 0x prefixed hex
+// address.
+type Provider interface {
+	Password(account string) (string, error)
+}
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func(account string) (string, error)
+
+func (f ProviderFunc) Password(account string) (string, error) { return f(account) }
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = make(map[string]func(arg string) (Provider, error))
+)
+
+func init() {
+	Register("file", func(path string) (Provider, error) {
+		if path == "" {
+			return nil, errors.New("file: path missing")
+		}
+		return File(path), nil
+	})
+	Register("env", func(name string) (Provider, error) {
+		if name == "" {
+			return nil, errors.New("env: variable name missing")
+		}
+		return Env(name), nil
+	})
+	Register("keyring", func(service string) (Provider, error) {
+		if service == "" {
+			service = DefaultKeyringService
+		}
+		return Keyring(service), nil
+	})
+	Register("cmd", func(command string) (Provider, error) {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return nil, errors.New("cmd: command missing")
+		}
+		return Command(args[0], args[1:]...), nil
+	})
+}
+
+// Register makes the sources of the given scheme available to Parse, open
+// creates the provider from the part of the source following the colon.
+func Register(scheme string, open func(arg string) (Provider, error)) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemes[scheme] = open
+}
+
+// Parse creates the provider of a scheme:argument source.
+func Parse(source string) (Provider, error) {
+	i := strings.Index(source, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("password source %q has no scheme, expected one of %s", source, strings.Join(schemeNames(), ", "))
+	}
+	schemesMu.RLock()
+	open, ok := schemes[source[:i]]
+	schemesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown password source scheme %q, expected one of %s", source[:i], strings.Join(schemeNames(), ", "))
+	}
+	return open(source[i+1:])
+}
+
+func schemeNames() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	var names []string
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// File reads the password from the first line of a file.
+type File string
+
+func (f File) Password(account string) (string, error) {
+	text, err := ioutil.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %v", err)
+	}
+	return firstLine(text), nil
+}
+
+// Env reads the password from an environment variable.
+type Env string
+
+func (e Env) Password(account string) (string, error) {
+	password, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("password variable %s not set", string(e))
+	}
+	return password, nil
+}
+
+// firstLine returns the first line of text without line terminator.
+func firstLine(text []byte) string {
+	return strings.TrimRight(strings.SplitN(string(text), "\n", 2)[0], "\r")
+}
+
+// Store selects the source of an account's password: its own if configured,
+// the default source otherwise.
+type Store struct {
+	def      Provider
+	accounts map[string]Provider
+}
+
+// NewStore parses the default source and the sources of single accounts,
+// keyed by address. Empty sources are ignored.
+func NewStore(def string, accounts map[string]string) (*Store, error) {
+	s := &Store{accounts: make(map[string]Provider)}
+	if def != "" {
+		p, err := Parse(def)
+		if err != nil {
+			return nil, err
+		}
+		s.def = p
+	}
+	for account, source := range accounts {
+		if source == "" {
+			continue
+		}
+		p, err := Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", account, err)
+		}
+		s.accounts[normalize(account)] = p
+	}
+	return s, nil
+}
+
+// SetDefault replaces the default source.
+func (s *Store) SetDefault(p Provider) {
+	s.def = p
+}
+
+// Has reports whether account has a password source.
+func (s *Store) Has(account string) bool {
+	_, ok := s.accounts[normalize(account)]
+	return ok || s.def != nil
+}
+
+// Password returns the password of account, ErrNotFound if neither the
+// account nor the store has a source.
+func (s *Store) Password(account string) (string, error) {
+	p, ok := s.accounts[normalize(account)]
+	if !ok {
+		p = s.def
+	}
+	if p == nil {
+		return "", ErrNotFound
+	}
+	return p.Password(normalize(account))
+}
+
+// normalize returns the lower case 0x prefixed form of an address.
+func normalize(account string) string {
+	account = strings.ToLower(account)
+	if !strings.HasPrefix(account, "0x") {
+		account = "0x" + account
+	}
+	return account
+}
@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const account = "0x5e4bd1e8d43f2a4c3b4f2bd3c8e7b1a2d3f4e5a6"
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "secrets")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "password")
+	ioutil.WriteFile(file, []byte("hunter2\r\nignored\n"), 0600)
+	os.Setenv("SECRETS_TEST_PASSWORD", "from env")
+	defer os.Unsetenv("SECRETS_TEST_PASSWORD")
+
+	for source, want := range map[string]string{
+		"file:" + file:                 "hunter2",
+		"env:SECRETS_TEST_PASSWORD":    "from env",
+		"cmd:echo from command":        "from command",
+		"cmd:printenv ASTRAIA_ACCOUNT": account,
+	} {
+		p, err := Parse(source)
+		assert.Nil(err, source)
+		password, err := p.Password(account)
+		assert.Nil(err, source)
+		assert.Equal(want, password, source)
+	}
+
+	for _, source := range []string{"hunter2", "vault:secret/wallet", "file:", "env:", "cmd:"} {
+		_, err := Parse(source)
+		assert.NotNil(err, source)
+	}
+
+	p, _ := Parse("env:SECRETS_TEST_UNSET")
+	_, err := p.Password(account)
+	assert.EqualError(err, "password variable SECRETS_TEST_UNSET not set")
+	p, _ = Parse("cmd:false")
+	_, err = p.Password(account)
+	assert.NotNil(err)
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+	Register("static", func(arg string) (Provider, error) {
+		return ProviderFunc(func(string) (string, error) { return arg, nil }), nil
+	})
+	p, err := Parse("static:s3cret")
+	assert.Nil(err)
+	password, _ := p.Password(account)
+	assert.Equal("s3cret", password)
+}
+
+func TestStore(t *testing.T) {
+	assert := assert.New(t)
+	Register("static", func(arg string) (Provider, error) {
+		return ProviderFunc(func(string) (string, error) { return arg, nil }), nil
+	})
+	store, err := NewStore("", map[string]string{"5E4BD1E8D43F2A4C3B4F2BD3C8E7B1A2D3F4E5A6": "static:own"})
+	assert.Nil(err)
+	password, err := store.Password(account)
+	assert.Nil(err)
+	assert.Equal("own", password)
+	_, err = store.Password("0x0000000000000000000000000000000000000001")
+	assert.Equal(ErrNotFound, err)
+	assert.True(store.Has(account))
+	assert.False(store.Has("0x0000000000000000000000000000000000000001"))
+
+	store.SetDefault(ProviderFunc(func(string) (string, error) { return "default", nil }))
+	password, _ = store.Password("0x0000000000000000000000000000000000000001")
+	assert.Equal("default", password)
+
+	_, err = NewStore("", map[string]string{account: "plain"})
+	assert.NotNil(err)
+}
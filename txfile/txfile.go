@@ -0,0 +1,309 @@
+// Package txfile defines the transaction file exchanged by the offline
+// transaction workflow: `astraia tx build` writes an unsigned transaction,
+// `astraia tx sign` adds the signature on an air-gapped machine and
+// `astraia tx broadcast` sends the signed transaction to the gateway.
+//
+// The file is JSON, quantities are 0x prefixed hex numbers:
+//
+//	{
+//	  "version": 1,
+//	  "network": "testnet", "chainId": 4242,
+//	  "tx": {
+//	    "from": "0x...", "to": "0x...", "nonce": "0x1", "gas": "0x5208",
+//	    "gasPrice": "0x1", "value": "0x3e8", "input": "0x"
+//	  },
+//	  "signed": "0x<rlp encoded signed transaction>"
+//	}
+//
+// network and chainId record the network the transaction was built
+// for, if any, and signed is absent until the transaction is signed. Unknown fields are
+// rejected, so that a file written by a newer version is not silently
+// misinterpreted.
+package txfile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/DSiSc/craft/types"
+	"github.com/DSiSc/crypto-suite/crypto"
+	"github.com/DSiSc/crypto-suite/rlp"
+	"github.com/DSiSc/p2p/common"
+	wtypes "github.com/DSiSc/wallet/core/types"
+)
+
+// Version is the version of the file format written by this package.
+const Version = 1
+
+var (
+	ErrNotSigned       = errors.New("transaction is not signed")
+	ErrAlreadySigned   = errors.New("transaction is already signed")
+	errUnknownVersion  = errors.New("unsupported transaction file version")
+	errSignedMismatch  = errors.New("signed transaction does not match the transaction fields")
+	errSignedSender    = errors.New("signed transaction is not signed by the sender")
+	errMissingSender   = errors.New("transaction has no sender")
+	errInvalidAddress  = errors.New("invalid hex address")
+	errInvalidQuantity = errors.New("invalid hex quantity")
+)
+
+// ErrWrongChain is returned by CheckChain for transactions of another chain.
+var ErrWrongChain = errors.New("transaction was built for another chain")
+
+// Tx holds the fields of the unsigned transaction.
+type Tx struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Nonce    string `json:"nonce"`
+	Gas      string `json:"gas"`
+	GasPrice string `json:"gasPrice"`
+	Value    string `json:"value"`
+	Input    string `json:"input"`
+}
+
+// File is the content of a transaction file.
+type File struct {
+	Version int    `json:"version"`
+	Network string `json:"network,omitempty"`
+	ChainID uint64 `json:"chainId,omitempty"`
+	Tx      Tx     `json:"tx"`
+	Signed  string `json:"signed,omitempty"`
+}
+
+// New creates the file of the unsigned transaction tx.
+func New(tx *types.Transaction) *File {
+	f := &File{
+		Version: Version,
+		Tx: Tx{
+			Nonce:    hexUint64(tx.Data.AccountNonce),
+			Gas:      hexUint64(tx.Data.GasLimit),
+			GasPrice: hexBig(tx.Data.Price),
+			Value:    hexBig(tx.Data.Amount),
+			Input:    fmt.Sprintf("0x%x", tx.Data.Payload),
+		},
+	}
+	if tx.Data.From != nil {
+		f.Tx.From = fmt.Sprintf("0x%x", *tx.Data.From)
+	}
+	if tx.Data.Recipient != nil {
+		f.Tx.To = fmt.Sprintf("0x%x", *tx.Data.Recipient)
+	}
+	return f
+}
+
+// CheckChain returns ErrWrongChain if the transaction was built for a chain
+// other than chainID. Transactions and networks without chain ID pass.
+func (f *File) CheckChain(chainID uint64) error {
+	if f.ChainID != 0 && chainID != 0 && f.ChainID != chainID {
+		return fmt.Errorf("%v: file %d (%s), selected %d", ErrWrongChain, f.ChainID, f.Network, chainID)
+	}
+	return nil
+}
+
+// Read loads and validates the transaction file at path.
+func Read(path string) (*File, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+
+	f := new(File)
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("%s: %v %d", path, errUnknownVersion, f.Version)
+	}
+	if _, err := f.Transaction(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// Write stores the file at path, replacing any previous content.
+func (f *File) Write(path string) error {
+	blob, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(blob, '\n'), 0644)
+}
+
+// Exists reports whether a file is present at path, so that commands do not
+// overwrite transactions by accident.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Transaction decodes the unsigned transaction.
+func (f *File) Transaction() (*types.Transaction, error) {
+	if f.Tx.From == "" {
+		return nil, errMissingSender
+	}
+	if !common.IsHexAddress(f.Tx.From) {
+		return nil, fmt.Errorf("from: %v %q", errInvalidAddress, f.Tx.From)
+	}
+	if f.Tx.To != "" && !common.IsHexAddress(f.Tx.To) {
+		return nil, fmt.Errorf("to: %v %q", errInvalidAddress, f.Tx.To)
+	}
+	nonce, err := parseUint64("nonce", f.Tx.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := parseUint64("gas", f.Tx.Gas)
+	if err != nil {
+		return nil, err
+	}
+	price, err := parseBig("gasPrice", f.Tx.GasPrice)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseBig("value", f.Tx.Value)
+	if err != nil {
+		return nil, err
+	}
+	input, err := hex.DecodeString(strings.TrimPrefix(f.Tx.Input, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("input: %v", err)
+	}
+
+	from := common.HexToAddress(f.Tx.From)
+	tx := &types.Transaction{
+		Data: types.TxData{
+			From:         &from,
+			AccountNonce: nonce,
+			GasLimit:     gas,
+			Price:        price,
+			Amount:       value,
+			Payload:      input,
+		},
+	}
+	if f.Tx.To != "" {
+		to := common.HexToAddress(f.Tx.To)
+		tx.Data.Recipient = &to
+	}
+	return tx, nil
+}
+
+// SetSigned records the RLP encoding of the signed transaction, checking that
+// it carries the fields and the sender of the unsigned one.
+func (f *File) SetSigned(raw []byte) error {
+	if f.Signed != "" {
+		return ErrAlreadySigned
+	}
+	var signed types.Transaction
+	if err := rlp.DecodeBytes(raw, &signed); err != nil {
+		return fmt.Errorf("invalid signed transaction: %v", err)
+	}
+	if err := f.checkSigned(&signed); err != nil {
+		return err
+	}
+	f.Signed = fmt.Sprintf("0x%x", raw)
+	return nil
+}
+
+// SignedTransaction decodes the signed transaction, checking again that it
+// carries the fields and the sender of the unsigned one, so that an edited
+// file is not broadcast.
+func (f *File) SignedTransaction() (*types.Transaction, error) {
+	raw, err := f.signedBytes()
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, fmt.Errorf("signed: %v", err)
+	}
+	if err := f.checkSigned(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SignedHash returns the hash of the signed transaction.
+func (f *File) SignedHash() (string, error) {
+	raw, err := f.signedBytes()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("0x%x", crypto.Keccak256(raw)), nil
+}
+
+func (f *File) signedBytes() ([]byte, error) {
+	if f.Signed == "" {
+		return nil, ErrNotSigned
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(f.Signed, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("signed: %v", err)
+	}
+	return raw, nil
+}
+
+// checkSigned compares the signed transaction with the unsigned one. The
+// sender is recovered from the signature, for the chain ID of the file.
+func (f *File) checkSigned(signed *types.Transaction) error {
+	unsigned, err := f.Transaction()
+	if err != nil {
+		return err
+	}
+	want, got := New(unsigned).Tx, New(signed).Tx
+	want.From, got.From = "", ""
+	if want != got {
+		return errSignedMismatch
+	}
+	var signer wtypes.Signer = wtypes.HomesteadSigner{}
+	if f.ChainID != 0 {
+		signer = wtypes.NewEIP155Signer(new(big.Int).SetUint64(f.ChainID))
+	}
+	sender, err := wtypes.Sender(signer, signed)
+	if err != nil {
+		return fmt.Errorf("%v: %v", errSignedSender, err)
+	}
+	if from := fmt.Sprintf("0x%x", sender); !strings.EqualFold(from, f.Tx.From) {
+		return fmt.Errorf("%v: signed by %s", errSignedSender, from)
+	}
+	return nil
+}
+
+func hexUint64(n uint64) string {
+	return fmt.Sprintf("0x%x", n)
+}
+
+func hexBig(n *big.Int) string {
+	if n == nil {
+		return "0x0"
+	}
+	return fmt.Sprintf("0x%x", n)
+}
+
+func parseUint64(name, s string) (uint64, error) {
+	n, err := parseBig(name, s)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("%s: %v %q", name, errInvalidQuantity, s)
+	}
+	return n.Uint64(), nil
+}
+
+func parseBig(name, s string) (*big.Int, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("%s: %v %q", name, errInvalidQuantity, s)
+	}
+	n, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("%s: %v %q", name, errInvalidQuantity, s)
+	}
+	return n, nil
+}
@@ -0,0 +1,140 @@
+package txfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DSiSc/craft/types"
+	"github.com/DSiSc/crypto-suite/crypto"
+	"github.com/DSiSc/crypto-suite/rlp"
+	"github.com/DSiSc/p2p/common"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTransaction() *types.Transaction {
+	from := common.HexToAddress("0x9f026b8fec907c3747ecd8f167e41e724def98b1")
+	to := common.HexToAddress("0x47c5e40890bce4a473a49d7501808b9633f29782")
+	return &types.Transaction{
+		Data: types.TxData{
+			From:         &from,
+			Recipient:    &to,
+			AccountNonce: 7,
+			GasLimit:     21000,
+			Price:        big.NewInt(1),
+			Amount:       big.NewInt(1000),
+			Payload:      []byte{0xca, 0xfe},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "txfile")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tx.json")
+
+	f := New(testTransaction())
+	assert.Equal(Tx{
+		From:     "0x9f026b8fec907c3747ecd8f167e41e724def98b1",
+		To:       "0x47c5e40890bce4a473a49d7501808b9633f29782",
+		Nonce:    "0x7",
+		Gas:      "0x5208",
+		GasPrice: "0x1",
+		Value:    "0x3e8",
+		Input:    "0xcafe",
+	}, f.Tx)
+	assert.False(Exists(path))
+	assert.Nil(f.Write(path))
+	assert.True(Exists(path))
+
+	read, err := Read(path)
+	assert.Nil(err)
+	assert.Equal(f, read)
+
+	tx, err := read.Transaction()
+	assert.Nil(err)
+	assert.Equal(testTransaction().Data, tx.Data)
+
+	_, err = read.SignedTransaction()
+	assert.Equal(ErrNotSigned, err)
+}
+
+func TestSetSigned(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "txfile")
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	key, _ := crypto.GenerateKey()
+	account, err := ks.ImportECDSA(key, "123")
+	assert.Nil(err)
+	sign := func(tx *types.Transaction, chainID int64) []byte {
+		signed, err := ks.SignTxWithPassphrase(account, "123", tx, big.NewInt(chainID))
+		assert.Nil(err)
+		raw, _ := rlp.EncodeToBytes(signed)
+		return raw
+	}
+	unsigned := testTransaction()
+	unsigned.Data.From = &account.Address
+	f := New(unsigned)
+	f.ChainID = 4242
+
+	other := testTransaction()
+	other.Data.From = &account.Address
+	other.Data.AccountNonce++
+	assert.Equal(errSignedMismatch, f.SetSigned(sign(other, 4242)))
+	err = f.SetSigned(sign(unsigned, 1))
+	assert.True(strings.HasPrefix(fmt.Sprint(err), errSignedSender.Error()), err)
+
+	raw := sign(unsigned, 4242)
+	assert.Nil(f.SetSigned(raw))
+	assert.Equal(ErrAlreadySigned, f.SetSigned(raw))
+
+	tx, err := f.SignedTransaction()
+	assert.Nil(err)
+	assert.Equal(uint64(7), tx.Data.AccountNonce)
+	hash, err := f.SignedHash()
+	assert.Nil(err)
+	assert.Equal(fmt.Sprintf("0x%x", crypto.Keccak256(raw)), hash)
+
+	// a field edited after signing no longer matches the signed transaction
+	f.Tx.Value = "0x3e9"
+	_, err = f.SignedTransaction()
+	assert.Equal(errSignedMismatch, err)
+}
+
+func TestReadInvalid(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "txfile")
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"version":   `{"version": 2, "tx": {"from": "0x9f026b8fec907c3747ecd8f167e41e724def98b1", "nonce": "0x0", "gas": "0x0", "gasPrice": "0x0", "value": "0x0", "input": "0x"}}`,
+		"unknown":   `{"version": 1, "tx": {"from": "0x9f026b8fec907c3747ecd8f167e41e724def98b1", "nonce": "0x0", "gas": "0x0", "gasPrice": "0x0", "value": "0x0", "input": "0x"}, "chain": 1}`,
+		"decimal":   `{"version": 1, "tx": {"from": "0x9f026b8fec907c3747ecd8f167e41e724def98b1", "nonce": "1", "gas": "0x0", "gasPrice": "0x0", "value": "0x0", "input": "0x"}}`,
+		"sender":    `{"version": 1, "tx": {"nonce": "0x0", "gas": "0x0", "gasPrice": "0x0", "value": "0x0", "input": "0x"}}`,
+		"from":      `{"version": 1, "tx": {"from": "0x01", "nonce": "0x0", "gas": "0x0", "gasPrice": "0x0", "value": "0x0", "input": "0x"}}`,
+		"recipient": `{"version": 1, "tx": {"from": "0x9f026b8fec907c3747ecd8f167e41e724def98b1", "to": "alice", "nonce": "0x0", "gas": "0x0", "gasPrice": "0x0", "value": "0x0", "input": "0x"}}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		ioutil.WriteFile(path, []byte(content), 0644)
+		_, err := Read(path)
+		assert.NotNil(err, name)
+	}
+}
+
+func TestCheckChain(t *testing.T) {
+	assert := assert.New(t)
+	f := New(testTransaction())
+	assert.Nil(f.CheckChain(2))
+
+	f.Network, f.ChainID = "testnet", 2
+	assert.Nil(f.CheckChain(2))
+	assert.Nil(f.CheckChain(0))
+	assert.NotNil(f.CheckChain(1))
+}
@@ -1,8 +1,14 @@
 package utils
 
 import (
+	"github.com/DSiSc/astraia/config"
+	"github.com/DSiSc/astraia/log"
+	"github.com/DSiSc/astraia/secrets"
 	"github.com/DSiSc/crypto-suite/common"
+	"github.com/DSiSc/wallet/accounts/keystore"
+	"github.com/fatih/color"
 	"github.com/urfave/cli"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	wutils "github.com/DSiSc/wallet/utils"
@@ -26,17 +32,9 @@ func NewApp(gitCommit, usage string) *cli.App {
 
 var(
 	// General settings
-	TestnetFlag = cli.BoolFlag{
-		Name:  "testnet",
-		Usage: "Ropsten network: pre-configured proof-of-work test network",
-	}
-	RinkebyFlag = cli.BoolFlag{
-		Name:  "rinkeby",
-		Usage: "Rinkeby network: pre-configured proof-of-authority test network",
-	}
-	GoerliFlag = cli.BoolFlag{
-		Name:  "goerli",
-		Usage: "Görli network: pre-configured proof-of-authority test network",
+	NetworkFlag = cli.StringFlag{
+		Name:  "network",
+		Usage: "DSiSc network defined in the networks section of the config file",
 	}
 
 	// ATM the url is left to the user and deployment to
@@ -54,28 +52,223 @@ var(
 		Name:  "preload",
 		Usage: "Comma separated list of JavaScript files to preload into the console",
 	}
+	OutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Output format of evaluated values: text or json",
+		Value: "text",
+	}
+	NoColorFlag = cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Disable colored output",
+	}
+	ContinueOnErrorFlag = cli.BoolFlag{
+		Name:  "continue-on-error",
+		Usage: "Keep evaluating the statements piped into the console after one failed",
+	}
+	PromptFlag = cli.StringFlag{
+		Name:  "prompt",
+		Usage: "Console prompt template, e.g. '{{.Network}} #{{.Block}}> ', see the README",
+	}
+
+	// Config file settings
+	ConfigFileFlag = cli.StringFlag{
+		Name:  "config",
+		Usage: "Config file, defaults to $ASTRAIA_CONFIG or light_client.yaml in ~/.astraia",
+	}
+	ProfileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "Named profile of the config file to apply, e.g. testnet",
+	}
+
+	// Signing approval settings
+	RulesFlag = cli.StringFlag{
+		Name:  "rules",
+		Usage: "JavaScript file defining ApproveTx, consulted before every local signature",
+	}
+	AuditLogFlag = cli.StringFlag{
+		Name:  "auditlog",
+		Usage: "File to append the signing approval decisions to",
+		Value: "audit.log",
+	}
+
+	// Logging settings
+	VerbosityFlag = cli.StringFlag{
+		Name:  "verbosity",
+		Usage: "Log level: crit, error, warn, info, debug, trace or 0-5",
+	}
+	LogFormatFlag = cli.StringFlag{
+		Name:  "logformat",
+		Usage: "Log format: text or json",
+	}
+	LogFileFlag = cli.StringFlag{
+		Name:  "logfile",
+		Usage: "Log file, rotated at log.maxsize megabytes, instead of standard error",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
-// if none (or the empty string) is specified. If the node is starting a testnet,
-// the a subdirectory of the specified datadir will be used.
+// if none (or the empty string) is specified. If a network is selected a
+// subdirectory named after the network is used.
 func MakeDataDir(ctx *cli.Context) string {
 	if path := ctx.GlobalString(wutils.DataDirFlag.Name); path != "" {
-		if ctx.GlobalBool(TestnetFlag.Name) {
-			return filepath.Join(path, "testnet")
-		}
-		if ctx.GlobalBool(RinkebyFlag.Name) {
-			return filepath.Join(path, "rinkeby")
+		conf, err := config.Get()
+		if err != nil {
+			return path
 		}
-		if ctx.GlobalBool(GoerliFlag.Name) {
-			return filepath.Join(path, "goerli")
+		network := ctx.GlobalString(NetworkFlag.Name)
+		if network == "" {
+			network = conf.Network
 		}
-		return path
+		return networkDataDir(conf, path, network)
 	}
 	Fatalf("Cannot determine default data directory, please set manually (--datadir)")
 	return ""
 }
 
+// networkDataDir returns the subdirectory of datadir named after the network
+// of conf, datadir itself for none. Earlier releases kept every network in
+// datadir, whose keystore stays in use until the subdirectory exists.
+func networkDataDir(conf *config.Config, datadir, network string) string {
+	n, ok := conf.LookupNetwork(network)
+	if !ok {
+		return datadir
+	}
+	dir := filepath.Join(datadir, n.Name)
+	if _, err := os.Stat(dir); os.IsNotExist(err) && hasKeys(filepath.Join(datadir, keystore.KeyStoreScheme)) {
+		log.Warn("Using the keystore of the data directory, move it to the network directory", "network", n.Name, "datadir", datadir, "networkdir", dir)
+		return datadir
+	}
+	return dir
+}
+
+// hasKeys reports whether the keystore directory holds any key file.
+func hasKeys(keydir string) bool {
+	files, err := ioutil.ReadDir(keydir)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if !f.IsDir() && !strings.HasPrefix(f.Name(), ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeConfig loads the config file selected by --config with the --profile
+// settings applied, terminating if it is invalid.
+func MakeConfig(ctx *cli.Context) *config.Config {
+	conf, err := config.Init(ctx.GlobalString(ConfigFileFlag.Name), ctx.GlobalString(ProfileFlag.Name))
+	if err != nil {
+		Fatalf("%v", err)
+	}
+	if err := ApplyNetwork(ctx, conf); err != nil {
+		Fatalf("%v", err)
+	}
+	return conf
+}
+
+// ApplyNetwork selects the network named by --network in conf, which takes precedence
+// over the network of the config file.
+func ApplyNetwork(ctx *cli.Context, conf *config.Config) error {
+	name := ctx.GlobalString(NetworkFlag.Name)
+	if name == "" {
+		return nil
+	}
+	if err := conf.SetNetwork(name); err != nil {
+		return err
+	}
+	return conf.Validate()
+}
+
+// SetupLogging configures the logger from the log section of the config file
+// and the --verbosity, --logformat and --logfile flags, which take precedence.
+// An invalid config file is reported by the commands loading it, logging
+// then starts from the defaults.
+func SetupLogging(ctx *cli.Context) error {
+	conf, err := config.Load(ctx.GlobalString(ConfigFileFlag.Name), ctx.GlobalString(ProfileFlag.Name))
+	if err != nil {
+		conf = config.Default()
+	}
+	options := conf.Log.Options()
+	if ctx.GlobalIsSet(VerbosityFlag.Name) {
+		options.Level = ctx.GlobalString(VerbosityFlag.Name)
+	}
+	if ctx.GlobalIsSet(LogFormatFlag.Name) {
+		options.Format = ctx.GlobalString(LogFormatFlag.Name)
+	}
+	if ctx.GlobalIsSet(LogFileFlag.Name) {
+		options.File = ctx.GlobalString(LogFileFlag.Name)
+	}
+	return log.Setup(options)
+}
+
+// MakeConsoleOutput returns the --output format of the console. Colors are
+// disabled with --no-color.
+func MakeConsoleOutput(ctx *cli.Context) string {
+	if ctx.GlobalBool(NoColorFlag.Name) {
+		color.NoColor = true
+	}
+	return ctx.GlobalString(OutputFlag.Name)
+}
+
+// MakeConsolePrompt returns the prompt template of the console: --prompt, or
+// the console.prompt setting of the config file.
+func MakeConsolePrompt(ctx *cli.Context, conf *config.Config) string {
+	if ctx.GlobalIsSet(PromptFlag.Name) {
+		return ctx.GlobalString(PromptFlag.Name)
+	}
+	return conf.Console.Prompt
+}
+
+// MakePasswords creates the password store of the passwords section of the
+// config file. The --password file takes precedence over its default source.
+func MakePasswords(ctx *cli.Context, conf *config.Config) *secrets.Store {
+	store, err := conf.Passwords.Store()
+	if err != nil {
+		Fatalf("Invalid password sources: %v", err)
+	}
+	if path := ctx.GlobalString(wutils.PasswordFileFlag.Name); path != "" {
+		store.SetDefault(secrets.File(path))
+	}
+	return store
+}
+
+// MakeKeystoreConfig resolves the keystore directory and scrypt parameters of
+// the console. The --keystore, --datadir and --lightkdf flags take precedence
+// over the keystore section of the config file; without either the keystore
+// of the wallet default data directory is used.
+func MakeKeystoreConfig(ctx *cli.Context, conf *config.Config) (keydir string, scryptN, scryptP int) {
+	scryptN, scryptP = keystore.StandardScryptN, keystore.StandardScryptP
+	if ctx.GlobalBool(wutils.LightKDFFlag.Name) || conf.Keystore.LightKDF {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+
+	if ctx.GlobalIsSet(wutils.KeyStoreDirFlag.Name) {
+		return ctx.GlobalString(wutils.KeyStoreDirFlag.Name), scryptN, scryptP
+	}
+	// both data directories keep the keys of each network apart
+	datadir := conf.Keystore.DataDir
+	if ctx.GlobalIsSet(wutils.DataDirFlag.Name) {
+		datadir = MakeDataDir(ctx)
+	} else if datadir != "" {
+		datadir = networkDataDir(conf, datadir, conf.Network)
+	}
+	keydir = conf.Keystore.Dir
+	switch {
+	case keydir != "" && datadir != "" && !filepath.IsAbs(keydir):
+		keydir = filepath.Join(datadir, keydir)
+	case keydir == "" && datadir != "":
+		keydir = filepath.Join(datadir, keystore.KeyStoreScheme)
+	case keydir == "":
+		_, _, defaultDir, err := wutils.AccountConfig(keystore.KeyStoreScheme)
+		if err != nil {
+			Fatalf("Cannot determine default keystore directory: %v", err)
+		}
+		keydir = defaultDir
+	}
+	return keydir, scryptN, scryptP
+}
 
 // MakeConsolePreloads retrieves the absolute paths for the console JavaScript
 // scripts to preload before starting.
@@ -0,0 +1,135 @@
+package web3ext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Help formats of WriteHelp.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+)
+
+// returnType splits the type off a return value description of the form
+// "type: description". The type is empty for other descriptions.
+func returnType(returns string) (string, string) {
+	if i := strings.Index(returns, ": "); i > 0 && !strings.Contains(returns[:i], " ") {
+		return returns[:i], returns[i+2:]
+	}
+	return "", returns
+}
+
+// Text returns the help of the method as shown by help(method) in the console.
+func (m *Method) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n%s\n", m.Signature(), m.Description)
+	if len(m.Params) > 0 {
+		b.WriteString("\nParameters:\n")
+		for i, param := range m.Params {
+			optional := ""
+			if param.Optional {
+				optional = ", optional"
+			}
+			fmt.Fprintf(&b, "  %d. %s (%s%s): %s\n", i+1, param.Name, param.Type, optional, param.Description)
+		}
+	}
+	if m.Returns != "" {
+		fmt.Fprintf(&b, "\nReturns: %s\n", m.Returns)
+	}
+	if m.Example != "" {
+		b.WriteString("\nExample:\n")
+		for _, line := range strings.Split(m.Example, "\n") {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	b.WriteString("\n")
+	if len(m.Aliases) > 0 {
+		fmt.Fprintf(&b, "Also available as %s.\n", strings.Join(m.Aliases, ", "))
+	}
+	fmt.Fprintf(&b, "JSON-RPC method: %s\n", m.Call)
+	return b.String()
+}
+
+// Markdown returns the help of the method in the layout of the README.
+func (m *Method) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#### %s\n\n%s\n\n", m.Call, m.Description)
+	fmt.Fprintf(&b, "`%s`", m.Signature())
+	for _, alias := range m.Aliases {
+		fmt.Fprintf(&b, ", `%s`", alias)
+	}
+	b.WriteString("\n")
+	if len(m.Params) > 0 {
+		b.WriteString("\n**Parameters**\n\n")
+		for i, param := range m.Params {
+			required := "required"
+			if param.Optional {
+				required = "optional"
+			}
+			fmt.Fprintf(&b, "%d. %s `%s` %s: %s\n", i+1, param.Name, param.Type, required, param.Description)
+		}
+	}
+	if m.Returns != "" {
+		b.WriteString("\n**Returns**\n\n")
+		if typ, description := returnType(m.Returns); typ != "" {
+			fmt.Fprintf(&b, "`%s` - %s\n", typ, description)
+		} else {
+			fmt.Fprintf(&b, "%s\n", description)
+		}
+	}
+	if m.Example != "" {
+		fmt.Fprintf(&b, "\n**Example**\n\n```\n%s\n```\n", m.Example)
+	}
+	return b.String()
+}
+
+// Index returns the signatures and descriptions of methods, one per line, as
+// shown by help() in the console.
+func Index(methods []Method) string {
+	var b strings.Builder
+	width := 0
+	for i := range methods {
+		if n := len(methods[i].Signature()); n > width {
+			width = n
+		}
+	}
+	for i := range methods {
+		description := methods[i].Description
+		if end := strings.Index(description, ". "); end >= 0 {
+			description = description[:end+1]
+		}
+		fmt.Fprintf(&b, "%-*s  %s\n", width, methods[i].Signature(), description)
+	}
+	return b.String()
+}
+
+// WriteHelp writes the help of methods to w in the given format, text or
+// markdown.
+func WriteHelp(w io.Writer, methods []Method, format string) error {
+	out := bufio.NewWriter(w)
+	switch format {
+	case FormatText:
+		for i := range methods {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString(methods[i].Text())
+		}
+	case FormatMarkdown:
+		namespace := ""
+		for i := range methods {
+			if ns := strings.SplitN(methods[i].Name, ".", 2)[0]; ns != namespace {
+				namespace = ns
+				fmt.Fprintf(out, "### %s\n\n", namespace)
+			}
+			out.WriteString(methods[i].Markdown())
+			out.WriteString("\n---\n\n")
+		}
+	default:
+		return fmt.Errorf("unknown help format %q, expected %s or %s", format, FormatText, FormatMarkdown)
+	}
+	return out.Flush()
+}
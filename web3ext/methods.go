@@ -0,0 +1,668 @@
+package web3ext
+
+import "strings"
+
+// Param describes a parameter of an API method.
+type Param struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Optional    bool   `json:"optional,omitempty"`
+	Description string `json:"description"`
+}
+
+// Method describes an API method of the console: the methods served by the
+// client itself and the ones of the web3ext modules.
+type Method struct {
+	Name        string   `json:"name"`              // Console name, e.g. personal.unlockAccount
+	Aliases     []string `json:"aliases,omitempty"` // Other console names of the method
+	Call        string   `json:"call"`              // JSON-RPC method
+	Property    bool     `json:"property,omitempty"`
+	Description string   `json:"description"`
+	Params      []Param  `json:"params,omitempty"`
+	Returns     string   `json:"returns,omitempty"`
+	Example     string   `json:"example,omitempty"`
+}
+
+// ParamNames returns the names of the parameters of the method.
+func (m *Method) ParamNames() []string {
+	names := make([]string, len(m.Params))
+	for i, param := range m.Params {
+		names[i] = param.Name
+	}
+	return names
+}
+
+// Signature returns the console name of the method followed by its parameter
+// names, e.g. personal.unlockAccount(address, password, duration).
+func (m *Method) Signature() string {
+	if m.Property {
+		return m.Name
+	}
+	return m.Name + "(" + strings.Join(m.ParamNames(), ", ") + ")"
+}
+
+// Describe returns the description of the method with the given console name,
+// alias or JSON-RPC method, nil if there is none.
+func Describe(name string) *Method {
+	for i := range Methods {
+		m := &Methods[i]
+		if m.Name == name || m.Call == name {
+			return m
+		}
+		for _, alias := range m.Aliases {
+			if alias == name {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	addressParam   = Param{Name: "address", Type: "string", Description: "Hexadecimal address of the account."}
+	blockParam     = Param{Name: "block", Type: "number|string", Description: "Block number, or one of \"latest\", \"earliest\" and \"pending\"."}
+	fileParam      = Param{Name: "file", Type: "string", Description: "Path of the file on the node."}
+	hashParam      = Param{Name: "hash", Type: "string", Description: "Hash of the transaction."}
+	blockHashParam = Param{Name: "hash", Type: "string", Description: "Hash of the block."}
+	numberParam    = Param{Name: "number", Type: "number", Description: "Block number."}
+	secondsParam   = Param{Name: "seconds", Type: "number", Description: "Duration of the recording in seconds."}
+	traceOptions   = Param{Name: "options", Type: "object", Optional: true, Description: "Tracer options, e.g. {tracer: \"callTracer\"}."}
+	passwordParam  = Param{Name: "password", Type: "string", Optional: true, Description: "Password of the keystore file of the sender, taken from the password source of the account or prompted for if missing."}
+	txParam        = Param{Name: "tx", Type: "object", Description: "Transaction with from, to, value, gas, gasPrice, nonce and data fields."}
+	urlParam       = Param{Name: "url", Type: "string", Description: "http or https URL of the gateway, path included."}
+)
+
+// Methods are the descriptions of the API methods of the console, sorted by
+// name. They are shown by help(method) and method.help in the console and
+// exported by astraia help-methods.
+var Methods = []Method{
+	{
+		Name:        "admin.gateway",
+		Call:        "admin_gateway",
+		Property:    true,
+		Description: "URL of the api gateway in use.",
+		Returns:     "string: the gateway URL.",
+		Example:     ">admin.gateway\n\"http://127.0.0.1:47768\"",
+	},
+	{
+		Name:        "admin.setGateway",
+		Call:        "admin_setGateway",
+		Description: "Switch the api gateway used by the console. The gateway must answer a JSON-RPC request before it replaces the current one, the TLS and authentication settings of the config file are kept.",
+		Params:      []Param{urlParam},
+		Returns:     "object: the previous and current gateway URL.",
+		Example:     ">admin.setGateway(\"https://gateway.example.com/rpc\")\n{\n  current: \"https://gateway.example.com/rpc\",\n  previous: \"http://127.0.0.1:47768\"\n}",
+	},
+	{
+		Name:        "crosschain.signQueryTransaction",
+		Aliases:     []string{"personal.signCrossQueryTransaction"},
+		Call:        "personal_signCrossQueryTransaction",
+		Description: "Sign a transaction querying the state of another chain. The signing rules are applied before signing.",
+		Params: []Param{
+			txParam,
+			{Name: "from", Type: "string", Description: "Address of the sender on the other chain."},
+			{Name: "chainFlag", Type: "string", Description: "Identifier of the other chain."},
+			passwordParam,
+		},
+		Returns: "string: the RLP-encoded signed transaction.",
+	},
+	{
+		Name:        "crosschain.signTransaction",
+		Aliases:     []string{"personal.signCrossTransaction"},
+		Call:        "personal_signCrossTransaction",
+		Description: "Sign a cross-chain transaction: the transaction is signed for the other chain and wrapped into a signed transaction of this chain. The signing rules are applied before signing.",
+		Params: []Param{
+			txParam,
+			{Name: "to", Type: "string", Description: "Address of the receiver on the other chain."},
+			{Name: "chainFlag", Type: "string", Description: "Identifier of the other chain."},
+			passwordParam,
+		},
+		Returns: "string: the RLP-encoded signed transaction.",
+		Example: ">crosschain.signTransaction({from: eth.accounts[0], to: \"0xb0c066aa7f29c34f5ad32f900e2349c9dba9642e\", value: 1, gas: 21000, gasPrice: 1, nonce: 1}, \"0xb0c066aa7f29c34f5ad32f900e2349c9dba9642e\", \"chainB\")\n\"0xf8d7...\"",
+	},
+	{
+		Name:        "crosschain.status",
+		Call:        "crosschain_status",
+		Description: "Track a cross-chain transaction sent through the api gateway.",
+		Params:      []Param{hashParam},
+		Returns:     "object: the hash, the status (pending, success or failed) and, once mined, the blockNumber.",
+		Example:     ">crosschain.status(\"0xef8dadde66af80a228e4899055f2ff202d3e6107904b0f05316ebfcc7a31a850\")\n{\n  blockNumber: \"0x1b4\",\n  hash: \"0xef8dadde66af80a228e4899055f2ff202d3e6107904b0f05316ebfcc7a31a850\",\n  status: \"success\"\n}",
+	},
+	{
+		Name:        "debug.backtraceAt",
+		Call:        "debug_backtraceAt",
+		Description: "Log a stack trace whenever the node behind the api gateway logs at a source location.",
+		Params:      []Param{{Name: "location", Type: "string", Description: "Source location, e.g. server.go:443."}},
+	},
+	{
+		Name:        "debug.blockProfile",
+		Call:        "debug_blockProfile",
+		Description: "Record a goroutine blocking profile of the node behind the api gateway.",
+		Params:      []Param{fileParam, secondsParam},
+	},
+	{
+		Name:        "debug.chaindbCompact",
+		Call:        "debug_chaindbCompact",
+		Description: "Compact the chain database of the node behind the api gateway.",
+	},
+	{
+		Name:        "debug.chaindbProperty",
+		Call:        "debug_chaindbProperty",
+		Description: "Property of the chain database of the node behind the api gateway.",
+		Params:      []Param{{Name: "property", Type: "string", Description: "Name of the property, e.g. leveldb.stats."}},
+		Returns:     "string: the value of the property.",
+	},
+	{
+		Name:        "debug.cpuProfile",
+		Call:        "debug_cpuProfile",
+		Description: "Record a CPU profile of the node behind the api gateway.",
+		Params:      []Param{fileParam, secondsParam},
+	},
+	{
+		Name:        "debug.dumpBlock",
+		Call:        "debug_dumpBlock",
+		Description: "State of all accounts at a block.",
+		Params:      []Param{numberParam},
+		Returns:     "object: the state root and the accounts.",
+	},
+	{
+		Name:        "debug.freeOSMemory",
+		Call:        "debug_freeOSMemory",
+		Description: "Return unused memory of the node behind the api gateway to the operating system.",
+	},
+	{
+		Name:        "debug.gcStats",
+		Call:        "debug_gcStats",
+		Description: "Garbage collection statistics of the node behind the api gateway.",
+		Returns:     "object: the statistics.",
+	},
+	{
+		Name:        "debug.getBadBlocks",
+		Call:        "debug_getBadBlocks",
+		Description: "Blocks the node behind the api gateway rejected recently.",
+		Returns:     "array: the hashes, blocks and RLP encodings of the blocks.",
+	},
+	{
+		Name:        "debug.getBlockRlp",
+		Call:        "debug_getBlockRlp",
+		Description: "RLP encoding of a block.",
+		Params:      []Param{numberParam},
+		Returns:     "string: the encoded block.",
+	},
+	{
+		Name:        "debug.getModifiedAccountsByHash",
+		Call:        "debug_getModifiedAccountsByHash",
+		Description: "Accounts modified between two blocks.",
+		Params: []Param{
+			{Name: "startHash", Type: "string", Description: "Hash of the first block."},
+			{Name: "endHash", Type: "string", Optional: true, Description: "Hash of the last block, the first block only if missing."},
+		},
+		Returns: "array: the addresses of the accounts.",
+	},
+	{
+		Name:        "debug.getModifiedAccountsByNumber",
+		Call:        "debug_getModifiedAccountsByNumber",
+		Description: "Accounts modified between two blocks.",
+		Params: []Param{
+			{Name: "startNumber", Type: "number", Description: "Number of the first block."},
+			{Name: "endNumber", Type: "number", Optional: true, Description: "Number of the last block, the first block only if missing."},
+		},
+		Returns: "array: the addresses of the accounts.",
+	},
+	{
+		Name:        "debug.goTrace",
+		Call:        "debug_goTrace",
+		Description: "Record a Go execution trace of the node behind the api gateway.",
+		Params:      []Param{fileParam, secondsParam},
+	},
+	{
+		Name:        "debug.memStats",
+		Call:        "debug_memStats",
+		Description: "Memory statistics of the node behind the api gateway.",
+		Returns:     "object: the statistics.",
+	},
+	{
+		Name:        "debug.metrics",
+		Call:        "debug_metrics",
+		Description: "Metrics of the node behind the api gateway.",
+		Params:      []Param{{Name: "raw", Type: "bool", Description: "Whether to return the raw values instead of rates."}},
+		Returns:     "object: the metrics.",
+	},
+	{
+		Name:        "debug.mutexProfile",
+		Call:        "debug_mutexProfile",
+		Description: "Record a mutex contention profile of the node behind the api gateway.",
+		Params:      []Param{fileParam, secondsParam},
+	},
+	{
+		Name:        "debug.preimage",
+		Call:        "debug_preimage",
+		Description: "Preimage of a sha3 hash known to the node behind the api gateway.",
+		Params:      []Param{{Name: "hash", Type: "string", Description: "The sha3 hash."}},
+		Returns:     "string: the preimage.",
+	},
+	{
+		Name:        "debug.printBlock",
+		Call:        "debug_printBlock",
+		Description: "Readable dump of a block.",
+		Params:      []Param{numberParam},
+		Returns:     "string: the dump.",
+	},
+	{
+		Name:        "debug.seedHash",
+		Call:        "debug_seedHash",
+		Description: "Proof of work seed hash of a block.",
+		Params:      []Param{numberParam},
+		Returns:     "string: the seed hash.",
+	},
+	{
+		Name:        "debug.setBlockProfileRate",
+		Call:        "debug_setBlockProfileRate",
+		Description: "Set the rate of goroutine blocking events recorded by the node behind the api gateway.",
+		Params:      []Param{{Name: "rate", Type: "number", Description: "Sampling rate, 0 to disable."}},
+	},
+	{
+		Name:        "debug.setGCPercent",
+		Call:        "debug_setGCPercent",
+		Description: "Set the garbage collection target of the node behind the api gateway.",
+		Params:      []Param{{Name: "percent", Type: "number", Description: "Target percentage, negative to disable."}},
+		Returns:     "number: the previous setting.",
+	},
+	{
+		Name:        "debug.setHead",
+		Call:        "debug_setHead",
+		Description: "Rewind the chain of the node behind the api gateway to a block.",
+		Params:      []Param{numberParam},
+	},
+	{
+		Name:        "debug.setMutexProfileFraction",
+		Call:        "debug_setMutexProfileFraction",
+		Description: "Set the rate of mutex contention events recorded by the node behind the api gateway.",
+		Params:      []Param{{Name: "rate", Type: "number", Description: "Sampling rate, 0 to disable."}},
+	},
+	{
+		Name:        "debug.stacks",
+		Call:        "debug_stacks",
+		Description: "Stack traces of all goroutines of the node behind the api gateway.",
+		Returns:     "string: the stack traces.",
+	},
+	{
+		Name:        "debug.standardTraceBadBlockToFile",
+		Call:        "debug_standardTraceBadBlockToFile",
+		Description: "Trace the transactions of a rejected block into files.",
+		Params:      []Param{blockHashParam, traceOptions},
+		Returns:     "array: the names of the files.",
+	},
+	{
+		Name:        "debug.standardTraceBlockToFile",
+		Call:        "debug_standardTraceBlockToFile",
+		Description: "Trace the transactions of a block into files.",
+		Params:      []Param{blockHashParam, traceOptions},
+		Returns:     "array: the names of the files.",
+	},
+	{
+		Name:        "debug.startCPUProfile",
+		Call:        "debug_startCPUProfile",
+		Description: "Start recording a CPU profile of the node behind the api gateway.",
+		Params:      []Param{fileParam},
+	},
+	{
+		Name:        "debug.startGoTrace",
+		Call:        "debug_startGoTrace",
+		Description: "Start recording a Go execution trace of the node behind the api gateway.",
+		Params:      []Param{fileParam},
+	},
+	{
+		Name:        "debug.stopCPUProfile",
+		Call:        "debug_stopCPUProfile",
+		Description: "Stop recording the CPU profile.",
+	},
+	{
+		Name:        "debug.stopGoTrace",
+		Call:        "debug_stopGoTrace",
+		Description: "Stop recording the Go execution trace.",
+	},
+	{
+		Name:        "debug.storageRangeAt",
+		Call:        "debug_storageRangeAt",
+		Description: "Storage of a contract after a transaction of a block.",
+		Params: []Param{
+			blockHashParam,
+			{Name: "txIndex", Type: "number", Description: "Index of the transaction in the block."},
+			{Name: "address", Type: "string", Description: "Address of the contract."},
+			{Name: "keyStart", Type: "string", Description: "First storage key to return."},
+			{Name: "maxResult", Type: "number", Description: "Maximum number of storage entries to return."},
+		},
+		Returns: "object: the storage entries and the next key.",
+	},
+	{
+		Name:        "debug.testSignCliqueBlock",
+		Call:        "debug_testSignCliqueBlock",
+		Description: "Sign a clique block for testing.",
+		Params: []Param{
+			{Name: "address", Type: "string", Description: "Address of the signer."},
+			numberParam,
+		},
+		Returns: "string: the signer recovered from the signature.",
+	},
+	{
+		Name:        "debug.traceBadBlock",
+		Call:        "debug_traceBadBlock",
+		Description: "Trace the transactions of a rejected block.",
+		Params:      []Param{blockHashParam},
+		Returns:     "array: the traces.",
+	},
+	{
+		Name:        "debug.traceBlock",
+		Call:        "debug_traceBlock",
+		Description: "Trace the transactions of an RLP-encoded block.",
+		Params:      []Param{{Name: "blockRlp", Type: "string", Description: "RLP encoding of the block."}, traceOptions},
+		Returns:     "array: the traces.",
+	},
+	{
+		Name:        "debug.traceBlockByHash",
+		Call:        "debug_traceBlockByHash",
+		Description: "Trace the transactions of a block.",
+		Params:      []Param{blockHashParam, traceOptions},
+		Returns:     "array: the traces.",
+	},
+	{
+		Name:        "debug.traceBlockByNumber",
+		Call:        "debug_traceBlockByNumber",
+		Description: "Trace the transactions of a block.",
+		Params:      []Param{numberParam, traceOptions},
+		Returns:     "array: the traces.",
+	},
+	{
+		Name:        "debug.traceBlockFromFile",
+		Call:        "debug_traceBlockFromFile",
+		Description: "Trace the transactions of a block stored RLP-encoded in a file.",
+		Params:      []Param{fileParam, traceOptions},
+		Returns:     "array: the traces.",
+	},
+	{
+		Name:        "debug.traceTransaction",
+		Call:        "debug_traceTransaction",
+		Description: "Trace a transaction.",
+		Params:      []Param{hashParam, traceOptions},
+		Returns:     "object: the trace.",
+	},
+	{
+		Name:        "debug.verbosity",
+		Call:        "debug_verbosity",
+		Description: "Change the log level of the console.",
+		Params:      []Param{{Name: "level", Type: "number|string", Description: "0-5 or one of crit, error, warn, info, debug and trace."}},
+		Returns:     "object: the previous and current level.",
+		Example:     ">debug.verbosity(4)\n{\n  current: \"debug\",\n  previous: \"info\"\n}",
+	},
+	{
+		Name:        "debug.vmodule",
+		Call:        "debug_vmodule",
+		Description: "Set the log levels of the source files of the node behind the api gateway.",
+		Params:      []Param{{Name: "pattern", Type: "string", Description: "Levels by file pattern, e.g. eth/*=5,p2p=4."}},
+	},
+	{
+		Name:        "debug.writeBlockProfile",
+		Call:        "debug_writeBlockProfile",
+		Description: "Write the goroutine blocking profile of the node behind the api gateway to a file.",
+		Params:      []Param{fileParam},
+	},
+	{
+		Name:        "debug.writeMemProfile",
+		Call:        "debug_writeMemProfile",
+		Description: "Write the allocation profile of the node behind the api gateway to a file.",
+		Params:      []Param{fileParam},
+	},
+	{
+		Name:        "debug.writeMutexProfile",
+		Call:        "debug_writeMutexProfile",
+		Description: "Write the mutex contention profile of the node behind the api gateway to a file.",
+		Params:      []Param{fileParam},
+	},
+	{
+		Name:        "eth.chainId",
+		Call:        "eth_chainId",
+		Description: "Chain ID used to sign transactions.",
+		Returns:     "string: the hexadecimal chain ID.",
+	},
+	{
+		Name:        "eth.getBalance",
+		Call:        "eth_getBalance",
+		Description: "Balance of an account.",
+		Params:      []Param{addressParam, blockParam},
+		Returns:     "number: the balance in wei.",
+		Example:     ">eth.getBalance(\"0xb0c066aa7f29c34f5ad32f900e2349c9dba9642e\", \"latest\")\n1000000000000000000",
+	},
+	{
+		Name:        "eth.getProof",
+		Call:        "eth_getProof",
+		Description: "Merkle proof of an account and some of its storage.",
+		Params: []Param{
+			addressParam,
+			{Name: "keys", Type: "array", Description: "Storage keys to prove."},
+			blockParam,
+		},
+		Returns: "object: the account and storage proofs.",
+	},
+	{
+		Name:        "eth.getRawTransaction",
+		Call:        "eth_getRawTransactionByHash",
+		Description: "RLP encoding of a transaction.",
+		Params:      []Param{hashParam},
+		Returns:     "string: the encoded transaction.",
+	},
+	{
+		Name:        "eth.getRawTransactionFromBlock",
+		Call:        "eth_getRawTransactionByBlockNumberAndIndex",
+		Description: "RLP encoding of a transaction of a block. A block hash calls eth_getRawTransactionByBlockHashAndIndex instead.",
+		Params: []Param{
+			{Name: "block", Type: "number|string", Description: "Number or hash of the block."},
+			{Name: "index", Type: "number", Description: "Index of the transaction in the block."},
+		},
+		Returns: "string: the encoded transaction.",
+	},
+	{
+		Name:        "eth.getTransaction",
+		Call:        "eth_getTransactionByHash",
+		Description: "Transaction with the given hash.",
+		Params:      []Param{hashParam},
+		Returns:     "object: the transaction, null if it is unknown.",
+	},
+	{
+		Name:        "eth.getTransactionCount",
+		Call:        "eth_getTransactionCount",
+		Description: "Number of transactions sent from an account, the nonce of its next transaction.",
+		Params:      []Param{addressParam, blockParam},
+		Returns:     "number: the transaction count.",
+		Example:     ">eth.getTransactionCount(\"0xb0c066aa7f29c34f5ad32f900e2349c9dba9642e\", \"latest\")\n3",
+	},
+	{
+		Name:        "eth.newWeb3",
+		Call:        "eth_newWeb3",
+		Description: "Switch the api gateway to plain http on the given host and port, see admin.setGateway.",
+		Params: []Param{
+			{Name: "hostname", Type: "string", Description: "IP or hostname of the node."},
+			{Name: "port", Type: "string", Description: "RPC port of the node."},
+		},
+	},
+	{
+		Name:        "eth.pendingTransactions",
+		Call:        "eth_pendingTransactions",
+		Property:    true,
+		Description: "Pending transactions sent from the accounts of the node behind the api gateway.",
+		Returns:     "array: the transactions.",
+	},
+	{
+		Name:        "eth.resend",
+		Call:        "eth_resend",
+		Description: "Replace a pending transaction with new gas settings.",
+		Params: []Param{
+			txParam,
+			{Name: "gasPrice", Type: "number", Optional: true, Description: "New gas price."},
+			{Name: "gas", Type: "number", Optional: true, Description: "New gas limit."},
+		},
+		Returns: "string: the hash of the new transaction.",
+	},
+	{
+		Name:        "eth.sendRawTransaction",
+		Call:        "eth_sendRawTransaction",
+		Description: "Send a signed transaction through the api gateway.",
+		Params:      []Param{{Name: "data", Type: "string", Description: "RLP-encoded signed transaction."}},
+		Returns:     "string: the hash of the transaction.",
+		Example:     ">eth.sendRawTransaction(personal.signTransaction(tx))\n\"0xef8dadde66af80a228e4899055f2ff202d3e6107904b0f05316ebfcc7a31a850\"",
+	},
+	{
+		Name:        "eth.sign",
+		Call:        "eth_sign",
+		Description: "Sign data with an account of the node behind the api gateway.",
+		Params: []Param{
+			addressParam,
+			{Name: "data", Type: "string", Description: "Hexadecimal data to sign."},
+		},
+		Returns: "string: the signature.",
+	},
+	{
+		Name:        "eth.signTransaction",
+		Call:        "eth_signTransaction",
+		Description: "Sign a transaction with an account of the node behind the api gateway.",
+		Params:      []Param{txParam},
+		Returns:     "object: the RLP encoding and fields of the signed transaction.",
+	},
+	{
+		Name:        "eth.submitTransaction",
+		Call:        "eth_submitTransaction",
+		Description: "Send a transaction signed by the node behind the api gateway.",
+		Params:      []Param{txParam},
+		Returns:     "string: the hash of the transaction.",
+	},
+	{
+		Name:        "lightclient.config",
+		Call:        "lightclient_config",
+		Property:    true,
+		Description: "Effective configuration of the console, without credentials.",
+		Returns:     "object: the gateway, timeout, authentication kind (none, basic or token), network, keystore, signer and whether signing rules are loaded.",
+	},
+	{
+		Name:        "lightclient.gateway",
+		Call:        "lightclient_gateway",
+		Property:    true,
+		Description: "Api gateway in use, whether it is reachable and the selected network.",
+		Returns:     "object: the endpoint, online state, network, chainId and explorer.",
+		Example:     ">lightclient.gateway\n{\n  chainId: 4242,\n  endpoint: \"https://gateway.example.com/rpc\",\n  explorer: \"https://explorer.example.com\",\n  network: \"testnet\",\n  online: true\n}",
+	},
+	{
+		Name:        "lightclient.setGateway",
+		Call:        "lightclient_setGateway",
+		Description: "Switch the api gateway used by the console, like admin.setGateway.",
+		Params:      []Param{urlParam},
+		Returns:     "object: the previous and current gateway URL.",
+	},
+	{
+		Name:        "net.version",
+		Call:        "net_version",
+		Property:    true,
+		Description: "Network ID of the node behind the api gateway.",
+		Returns:     "string: the network ID.",
+	},
+	{
+		Name:        "personal.deriveAccount",
+		Call:        "personal_deriveAccount",
+		Description: "Derive the account at an index of the mnemonic wallet into the keystore.",
+		Params: []Param{
+			{Name: "index", Type: "number", Description: "Index of the account below the wallet derivation path."},
+			{Name: "password", Type: "string", Optional: true, Description: "Password of the wallet, prompted for if missing."},
+		},
+		Returns: "string: the address of the account.",
+		Example: ">personal.deriveAccount(5, \"123\")\n\"0x...\"",
+	},
+	{
+		Name:        "personal.listAccounts",
+		Call:        "personal_listAccounts",
+		Description: "List the accounts of a keystore directory.",
+		Params:      []Param{{Name: "keystore", Type: "string", Optional: true, Description: "Keystore directory, the one of the console if null."}},
+		Returns:     "The accounts and their key files are printed.",
+	},
+	{
+		Name:        "personal.lockAccount",
+		Call:        "personal_lockAccount",
+		Description: "Lock an account, removing its decrypted key from memory.",
+		Params:      []Param{addressParam},
+		Example:     ">personal.lockAccount(\"0xe26fad38b09db076afdd05bab8df8119c870bbf7\")",
+	},
+	{
+		Name:        "personal.newAccount",
+		Call:        "personal_newAccount",
+		Description: "Create an account in the keystore.",
+		Params:      []Param{{Name: "password", Type: "string", Description: "Password encrypting the key file."}},
+		Returns:     "The address of the account is printed.",
+		Example:     ">personal.newAccount(\"123\")\nAddress: {aaacc574e67f1e2a357e05bcebb1aa6596500b47}",
+	},
+	{
+		Name:        "personal.newMnemonicWallet",
+		Call:        "personal_newMnemonicWallet",
+		Description: "Create a mnemonic wallet and derive its first accounts into the keystore.",
+		Params: []Param{
+			{Name: "password", Type: "string", Optional: true, Description: "Password protecting the wallet and the derived accounts, prompted for if missing."},
+			{Name: "mnemonic", Type: "string", Optional: true, Description: "Mnemonic to restore, a new one is generated if missing."},
+			{Name: "count", Type: "number", Optional: true, Description: "Number of accounts to derive, defaults to 1."},
+		},
+		Returns: "object: the derivation path, the derived accounts and, if generated, the mnemonic.",
+	},
+	{
+		Name:        "personal.openWallet",
+		Call:        "personal_openWallet",
+		Description: "Select the backend of the signing methods: the file keystore (keystore://, the default) or an external signer process (extsigner://<command> [args...]).",
+		Params: []Param{
+			{Name: "url", Type: "string", Description: "URL of the signer."},
+			{Name: "passphrase", Type: "string", Optional: true, Description: "PIN or passphrase of the signer, prompted for if the signer asks for one."},
+		},
+		Returns: "string: the URL of the signer now in use.",
+		Example: ">personal.openWallet(\"extsigner:///usr/local/bin/signer --device 0\")\n\"extsigner:///usr/local/bin/signer --device 0\"",
+	},
+	{
+		Name:        "personal.signTransaction",
+		Call:        "personal_signTransaction",
+		Description: "Sign a transaction with an account of the keystore or the signer opened with personal.openWallet. The signing rules are applied before signing.",
+		Params:      []Param{txParam, passwordParam},
+		Returns:     "string: the RLP-encoded signed transaction.",
+		Example:     ">personal.signTransaction({from: \"0xb0c066aa7f29c34f5ad32f900e2349c9dba9642e\", to: \"0xb0c066aa7f29c34f5ad32f900e2349c9dba9642e\", value: 1, gas: 21000, gasPrice: 1, nonce: 1}, \"123\")\n\"0xf877...\"",
+	},
+	{
+		Name:        "personal.unlockAccount",
+		Call:        "personal_unlockAccount",
+		Description: "Unlock an account of the keystore.",
+		Params: []Param{
+			addressParam,
+			{Name: "password", Type: "string", Optional: true, Description: "Password of the key file, taken from the password source of the account or prompted for if missing."},
+			{Name: "duration", Type: "number", Optional: true, Description: "Ignored, accounts stay unlocked until locked."},
+		},
+		Example: ">personal.unlockAccount(\"0xe26fad38b09db076afdd05bab8df8119c870bbf7\", \"123\")",
+	},
+	{
+		Name:        "rpc.modules",
+		Call:        "rpc_modules",
+		Property:    true,
+		Description: "API modules of the console: the local ones and, while online, the ones the api gateway advertises.",
+		Returns:     "object: the versions by module name.",
+	},
+	{
+		Name:        "txpool.content",
+		Call:        "txpool_content",
+		Property:    true,
+		Description: "Pending and queued transactions of the node behind the api gateway.",
+		Returns:     "object: the transactions by sender and nonce.",
+	},
+	{
+		Name:        "txpool.inspect",
+		Call:        "txpool_inspect",
+		Property:    true,
+		Description: "Summary of the pending and queued transactions of the node behind the api gateway.",
+		Returns:     "object: the summaries by sender and nonce.",
+	},
+	{
+		Name:        "txpool.status",
+		Call:        "txpool_status",
+		Property:    true,
+		Description: "Number of pending and queued transactions of the node behind the api gateway.",
+		Returns:     "object: the pending and queued counts.",
+	},
+}
@@ -0,0 +1,80 @@
+package web3ext
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	propertyRegexp = regexp.MustCompile(`property: '(\w+)'`)
+	memberRegexp   = regexp.MustCompile(`name: '(\w+)'`)
+)
+
+func TestMethodsDescribed(t *testing.T) {
+	assert := assert.New(t)
+	// every method and property of the extensions
+	for module, js := range Modules {
+		ns := propertyRegexp.FindStringSubmatch(js)
+		if !assert.NotNil(ns, module) {
+			continue
+		}
+		for _, member := range memberRegexp.FindAllStringSubmatch(js, -1) {
+			name := ns[1] + "." + member[1]
+			assert.NotNil(Describe(name), name)
+		}
+	}
+	// the methods served by the client and the built-in methods of web3.js
+	for _, name := range []string{
+		"eth.getBalance", "eth.getTransaction", "eth.getTransactionCount", "eth.newWeb3", "eth.sendRawTransaction",
+		"personal.listAccounts", "personal.lockAccount", "personal.newAccount", "personal.unlockAccount",
+		"personal.signCrossTransaction", "personal.signCrossQueryTransaction",
+	} {
+		assert.NotNil(Describe(name), name)
+	}
+
+	names := make([]string, len(Methods))
+	for i, m := range Methods {
+		names[i] = m.Name
+		assert.NotEmpty(m.Call, m.Name)
+		assert.NotEmpty(m.Description, m.Name)
+	}
+	assert.True(sort.StringsAreSorted(names))
+}
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+	m := Describe("personal_signCrossTransaction")
+	if !assert.NotNil(m) {
+		return
+	}
+	assert.Equal(m, Describe("crosschain.signTransaction"))
+	assert.Equal(m, Describe("personal.signCrossTransaction"))
+	assert.Equal("crosschain.signTransaction(tx, to, chainFlag, password)", m.Signature())
+	assert.Equal("admin.gateway", Describe("admin.gateway").Signature())
+	assert.Nil(Describe("admin"))
+}
+
+func TestWriteHelp(t *testing.T) {
+	assert := assert.New(t)
+	methods := []Method{*Describe("crosschain.status"), *Describe("debug.verbosity")}
+
+	var out bytes.Buffer
+	assert.Nil(WriteHelp(&out, methods, FormatMarkdown))
+	assert.True(strings.HasPrefix(out.String(), "### crosschain\n\n#### crosschain_status\n\nTrack a cross-chain transaction"))
+	assert.Contains(out.String(), "1. hash `string` required: Hash of the transaction.\n")
+	assert.Contains(out.String(), "**Returns**\n\n`object` - the hash")
+	assert.Contains(out.String(), "### debug\n\n#### debug_verbosity")
+
+	out.Reset()
+	assert.Nil(WriteHelp(&out, methods[:1], FormatText))
+	assert.True(strings.HasPrefix(out.String(), "crosschain.status(hash)\n\nTrack a cross-chain transaction"))
+	assert.Contains(out.String(), "  1. hash (string): Hash of the transaction.\n")
+	assert.Contains(out.String(), "JSON-RPC method: crosschain_status\n")
+
+	assert.NotNil(WriteHelp(&out, methods, "html"))
+}
@@ -14,204 +14,35 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// package web3ext contains geth specific web3.js extensions.
+// package web3ext contains the astraia specific web3.js extensions.
 package web3ext
 
 var Modules = map[string]string{
-	"accounting": AccountingJs,
-	"admin":      AdminJs,
-	"chequebook": ChequebookJs,
-	"clique":     CliqueJs,
-	"ethash":     EthashJs,
-	"debug":      DebugJs,
-	"eth":        EthJs,
-	"miner":      MinerJs,
-	"net":        NetJs,
-	"personal":   PersonalJs,
-	"rpc":        RpcJs,
-	"shh":        ShhJs,
-	"swarmfs":    SwarmfsJs,
-	"txpool":     TxpoolJs,
+	"admin":       AdminJs,
+	"crosschain":  CrosschainJs,
+	"debug":       DebugJs,
+	"eth":         EthJs,
+	"lightclient": LightclientJs,
+	"net":         NetJs,
+	"personal":    PersonalJs,
+	"rpc":         RpcJs,
+	"txpool":      TxpoolJs,
 }
 
-const ChequebookJs = `
-web3._extend({
-	property: 'chequebook',
-	methods: [
-		new web3._extend.Method({
-			name: 'deposit',
-			call: 'chequebook_deposit',
-			params: 1,
-			inputFormatter: [null]
-		}),
-		new web3._extend.Property({
-			name: 'balance',
-			getter: 'chequebook_balance',
-			outputFormatter: web3._extend.utils.toDecimal
-		}),
-		new web3._extend.Method({
-			name: 'cash',
-			call: 'chequebook_cash',
-			params: 1,
-			inputFormatter: [null]
-		}),
-		new web3._extend.Method({
-			name: 'issue',
-			call: 'chequebook_issue',
-			params: 2,
-			inputFormatter: [null, null]
-		}),
-	]
-});
-`
-
-const CliqueJs = `
-web3._extend({
-	property: 'clique',
-	methods: [
-		new web3._extend.Method({
-			name: 'getSnapshot',
-			call: 'clique_getSnapshot',
-			params: 1,
-			inputFormatter: [null]
-		}),
-		new web3._extend.Method({
-			name: 'getSnapshotAtHash',
-			call: 'clique_getSnapshotAtHash',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'getSigners',
-			call: 'clique_getSigners',
-			params: 1,
-			inputFormatter: [null]
-		}),
-		new web3._extend.Method({
-			name: 'getSignersAtHash',
-			call: 'clique_getSignersAtHash',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'propose',
-			call: 'clique_propose',
-			params: 2
-		}),
-		new web3._extend.Method({
-			name: 'discard',
-			call: 'clique_discard',
-			params: 1
-		}),
-	],
-	properties: [
-		new web3._extend.Property({
-			name: 'proposals',
-			getter: 'clique_proposals'
-		}),
-	]
-});
-`
-
-const EthashJs = `
-web3._extend({
-	property: 'ethash',
-	methods: [
-		new web3._extend.Method({
-			name: 'getWork',
-			call: 'ethash_getWork',
-			params: 0
-		}),
-		new web3._extend.Method({
-			name: 'getHashrate',
-			call: 'ethash_getHashrate',
-			params: 0
-		}),
-		new web3._extend.Method({
-			name: 'submitWork',
-			call: 'ethash_submitWork',
-			params: 3,
-		}),
-		new web3._extend.Method({
-			name: 'submitHashRate',
-			call: 'ethash_submitHashRate',
-			params: 2,
-		}),
-	]
-});
-`
-
 const AdminJs = `
 web3._extend({
 	property: 'admin',
 	methods: [
 		new web3._extend.Method({
-			name: 'addPeer',
-			call: 'admin_addPeer',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'removePeer',
-			call: 'admin_removePeer',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'addTrustedPeer',
-			call: 'admin_addTrustedPeer',
+			name: 'setGateway',
+			call: 'admin_setGateway',
 			params: 1
 		}),
-		new web3._extend.Method({
-			name: 'removeTrustedPeer',
-			call: 'admin_removeTrustedPeer',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'exportChain',
-			call: 'admin_exportChain',
-			params: 1,
-			inputFormatter: [null]
-		}),
-		new web3._extend.Method({
-			name: 'importChain',
-			call: 'admin_importChain',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'sleepBlocks',
-			call: 'admin_sleepBlocks',
-			params: 2
-		}),
-		new web3._extend.Method({
-			name: 'startRPC',
-			call: 'admin_startRPC',
-			params: 4,
-			inputFormatter: [null, null, null, null]
-		}),
-		new web3._extend.Method({
-			name: 'stopRPC',
-			call: 'admin_stopRPC'
-		}),
-		new web3._extend.Method({
-			name: 'startWS',
-			call: 'admin_startWS',
-			params: 4,
-			inputFormatter: [null, null, null, null]
-		}),
-		new web3._extend.Method({
-			name: 'stopWS',
-			call: 'admin_stopWS'
-		}),
 	],
 	properties: [
 		new web3._extend.Property({
-			name: 'nodeInfo',
-			getter: 'admin_nodeInfo'
-		}),
-		new web3._extend.Property({
-			name: 'peers',
-			getter: 'admin_peers'
-		}),
-		new web3._extend.Property({
-			name: 'datadir',
-			getter: 'admin_datadir'
+			name: 'gateway',
+			getter: 'admin_gateway'
 		}),
 	]
 });
@@ -524,51 +355,6 @@ web3._extend({
 });
 `
 
-const MinerJs = `
-web3._extend({
-	property: 'miner',
-	methods: [
-		new web3._extend.Method({
-			name: 'start',
-			call: 'miner_start',
-			params: 1,
-			inputFormatter: [null]
-		}),
-		new web3._extend.Method({
-			name: 'stop',
-			call: 'miner_stop'
-		}),
-		new web3._extend.Method({
-			name: 'setEtherbase',
-			call: 'miner_setEtherbase',
-			params: 1,
-			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
-		}),
-		new web3._extend.Method({
-			name: 'setExtra',
-			call: 'miner_setExtra',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'setGasPrice',
-			call: 'miner_setGasPrice',
-			params: 1,
-			inputFormatter: [web3._extend.utils.fromDecimal]
-		}),
-		new web3._extend.Method({
-			name: 'setRecommitInterval',
-			call: 'miner_setRecommitInterval',
-			params: 1,
-		}),
-		new web3._extend.Method({
-			name: 'getHashrate',
-			call: 'miner_getHashrate'
-		}),
-	],
-	properties: []
-});
-`
-
 const NetJs = `
 web3._extend({
 	property: 'net',
@@ -592,6 +378,23 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'openWallet',
+			call: 'personal_openWallet',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'newMnemonicWallet',
+			call: 'personal_newMnemonicWallet',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'deriveAccount',
+			call: 'personal_deriveAccount',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 	],
 	properties: [
 		
@@ -612,50 +415,6 @@ web3._extend({
 });
 `
 
-const ShhJs = `
-web3._extend({
-	property: 'shh',
-	methods: [
-	],
-	properties:
-	[
-		new web3._extend.Property({
-			name: 'version',
-			getter: 'shh_version',
-			outputFormatter: web3._extend.utils.toDecimal
-		}),
-		new web3._extend.Property({
-			name: 'info',
-			getter: 'shh_info'
-		}),
-	]
-});
-`
-
-const SwarmfsJs = `
-web3._extend({
-	property: 'swarmfs',
-	methods:
-	[
-		new web3._extend.Method({
-			name: 'mount',
-			call: 'swarmfs_mount',
-			params: 2
-		}),
-		new web3._extend.Method({
-			name: 'unmount',
-			call: 'swarmfs_unmount',
-			params: 1
-		}),
-		new web3._extend.Method({
-			name: 'listmounts',
-			call: 'swarmfs_listmounts',
-			params: 0
-		}),
-	]
-});
-`
-
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
@@ -683,45 +442,50 @@ web3._extend({
 });
 `
 
-const AccountingJs = `
+const CrosschainJs = `
 web3._extend({
-	property: 'accounting',
+	property: 'crosschain',
 	methods: [
-		new web3._extend.Property({
-			name: 'balance',
-			getter: 'account_balance'
-		}),
-		new web3._extend.Property({
-			name: 'balanceCredit',
-			getter: 'account_balanceCredit'
-		}),
-		new web3._extend.Property({
-			name: 'balanceDebit',
-			getter: 'account_balanceDebit'
-		}),
-		new web3._extend.Property({
-			name: 'bytesCredit',
-			getter: 'account_bytesCredit'
+		new web3._extend.Method({
+			name: 'signTransaction',
+			call: 'personal_signCrossTransaction',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, web3._extend.formatters.inputAddressFormatter, null, null]
 		}),
-		new web3._extend.Property({
-			name: 'bytesDebit',
-			getter: 'account_bytesDebit'
+		new web3._extend.Method({
+			name: 'signQueryTransaction',
+			call: 'personal_signCrossQueryTransaction',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, web3._extend.formatters.inputAddressFormatter, null, null]
 		}),
-		new web3._extend.Property({
-			name: 'msgCredit',
-			getter: 'account_msgCredit'
+		new web3._extend.Method({
+			name: 'status',
+			call: 'crosschain_status',
+			params: 1
 		}),
-		new web3._extend.Property({
-			name: 'msgDebit',
-			getter: 'account_msgDebit'
+	],
+	properties: []
+});
+`
+
+const LightclientJs = `
+web3._extend({
+	property: 'lightclient',
+	methods: [
+		new web3._extend.Method({
+			name: 'setGateway',
+			call: 'lightclient_setGateway',
+			params: 1
 		}),
+	],
+	properties: [
 		new web3._extend.Property({
-			name: 'peerDrops',
-			getter: 'account_peerDrops'
+			name: 'gateway',
+			getter: 'lightclient_gateway'
 		}),
 		new web3._extend.Property({
-			name: 'selfDrops',
-			getter: 'account_selfDrops'
+			name: 'config',
+			getter: 'lightclient_config'
 		}),
 	]
 });